@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const accessTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+type AccessFormat int
+
+const (
+	CommonLogFormat AccessFormat = iota
+	CombinedLogFormat
+)
+
+type AccessFields struct {
+	RemoteAddr string
+	User       string
+	Time       time.Time
+	Method     string
+	URI        string
+	Proto      string
+	Status     int
+	Size       int64
+	Referer    string
+	UserAgent  string
+}
+
+func (f AccessFields) Format(format AccessFormat) []byte {
+	t := f.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	b := make([]byte, 0, 128)
+	b = appendAccessToken(b, accessValue(f.RemoteAddr))
+	b = append(b, " - "...)
+	b = appendAccessToken(b, accessValue(f.User))
+	b = append(b, " ["...)
+	b = t.AppendFormat(b, accessTimeLayout)
+	b = append(b, "] \""...)
+	b = appendAccessQuoted(b, strings.TrimSpace(f.Method+" "+f.URI+" "+f.Proto))
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(f.Status), 10)
+	b = append(b, ' ')
+	if f.Size > 0 {
+		b = strconv.AppendInt(b, f.Size, 10)
+	} else {
+		b = append(b, '-')
+	}
+
+	if format == CombinedLogFormat {
+		b = append(b, " \""...)
+		b = appendAccessQuoted(b, accessValue(f.Referer))
+		b = append(b, "\" \""...)
+		b = appendAccessQuoted(b, accessValue(f.UserAgent))
+		b = append(b, '"')
+	}
+
+	return append(b, '\n')
+}
+
+func accessValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendAccessToken escapes an unquoted field like appendAccessQuoted, and
+// also escapes spaces so the field stays a single token.
+func appendAccessToken(b []byte, s string) []byte {
+	return appendAccessEscaped(b, s, true)
+}
+
+func appendAccessQuoted(b []byte, s string) []byte {
+	return appendAccessEscaped(b, s, false)
+}
+
+func appendAccessEscaped(b []byte, s string, space bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c == 0x7f || space && c == ' ':
+			b = append(b, `\x`...)
+			b = append(b, "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// AccessLog writes an access log line in the format selected by
+// WithAccessLogFormat to the Info destinations, without the level tag,
+// caller or fields.
+func (l *Logger) AccessLog(fields AccessFields) {
+	if !l.enabled(Info) || l.silent {
+		return
+	}
+
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
+
+	b := fields.Format(l.accessFormat)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.logger(Info).Writer().Write(b)
+}
@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessFieldsFormatEscapes(t *testing.T) {
+	f := AccessFields{
+		Time:       time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		RemoteAddr: "10.0.0.1\n",
+		User:       `bob "x" y`,
+		Method:     "GET",
+		URI:        `/a"b`,
+		Proto:      "HTTP/1.1",
+		Status:     200,
+	}
+	want := `10.0.0.1\x0a - bob\x20\"x\"\x20y [06/May/2024:07:08:09 +0000] "GET /a\"b HTTP/1.1" 200 -` + "\n"
+	if got := string(f.Format(CommonLogFormat)); got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// batchLoop runs the background loop shared by the batching writers. It
+// calls send when notified and on every interval, and a final time with
+// final set when stopped.
+type batchLoop struct {
+	flush chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func (b *batchLoop) start(interval time.Duration, send func(final bool)) {
+	b.flush = make(chan struct{}, 1)
+	b.done = make(chan struct{})
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				send(false)
+			case <-b.flush:
+				send(false)
+			case <-b.done:
+				send(true)
+				return
+			}
+		}
+	}()
+}
+
+// notify asks the loop to send without waiting for the interval.
+func (b *batchLoop) notify() {
+	select {
+	case b.flush <- struct{}{}:
+	default:
+	}
+}
+
+// stop runs the final send and waits for the loop to exit.
+func (b *batchLoop) stop() {
+	close(b.done)
+	b.wg.Wait()
+}
+
+// retry calls fn until it succeeds or has been retried retries times,
+// doubling backoff between attempts.
+func retry(retries int, backoff time.Duration, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import "strconv"
+
+// ByteSize is a byte count written in text output with binary units, such
+// as 1.5MiB, or decimal units with WithDecimalBytes. Formatters such as
+// JSONFormatter keep the raw number.
+type ByteSize int64
+
+func Bytes(key string, n int64) Field {
+	return Field{Key: key, Value: ByteSize(n)}
+}
+
+func (b ByteSize) String() string {
+	return formatBytes(int64(b), 1024, []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"})
+}
+
+type decimalByteSize int64
+
+func (b decimalByteSize) String() string {
+	return formatBytes(int64(b), 1000, []string{"kB", "MB", "GB", "TB", "PB", "EB"})
+}
+
+func formatBytes(n, base int64, units []string) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs < base {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+
+	v := float64(n)
+	i := -1
+	for (v >= float64(base) || v <= -float64(base)) && i < len(units)-1 {
+		v /= float64(base)
+		i++
+	}
+
+	s := strconv.FormatFloat(v, 'f', 1, 64)
+	if len(s) > 2 && s[len(s)-2:] == ".0" {
+		s = s[:len(s)-2]
+	}
+	return s + units[i]
+}
+
+func decimalByteFields(fields []Field) []Field {
+	var converted []Field
+	for i, f := range fields {
+		b, ok := f.Value.(ByteSize)
+		if !ok {
+			continue
+		}
+		if converted == nil {
+			converted = append([]Field(nil), fields...)
+		}
+		converted[i].Value = decimalByteSize(b)
+	}
+	if converted == nil {
+		return fields
+	}
+	return converted
+}
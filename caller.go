@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const callerFlags = log.Lshortfile | log.Llongfile
+
+// callDepth is the number of frames between the user's call site and the
+// frame that resolves it: the user calls a logging method, which calls log,
+// which calls output, which resolves the caller. Every logging method must
+// call log directly.
+const callDepth = 4
+
+type frame struct {
+	function string
+	file     string
+	line     int
+}
+
+type callerCache struct {
+	frames sync.Map
+}
+
+func (c *callerCache) lookup(pc uintptr) []frame {
+	if v, ok := c.frames.Load(pc); ok {
+		return v.([]frame)
+	}
+
+	var frames []frame
+	iter := runtime.CallersFrames([]uintptr{pc})
+	for {
+		f, more := iter.Next()
+		frames = append(frames, frame{function: f.Function, file: f.File, line: f.Line})
+		if !more {
+			break
+		}
+	}
+
+	c.frames.Store(pc, frames)
+
+	return frames
+}
+
+func (l *Logger) walkFrames(pcs []uintptr, fn func(f frame) bool) {
+	if l.callerCache != nil {
+		for _, pc := range pcs {
+			for _, f := range l.callerCache.lookup(pc) {
+				if !fn(f) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		if !fn(frame{function: f.Function, file: f.File, line: f.Line}) || !more {
+			return
+		}
+	}
+}
+
+func (l *Logger) caller(depth int) frame {
+	var pcs [32]uintptr
+	n := runtime.Callers(depth+1, pcs[:])
+	if n == 0 {
+		return frame{file: "???"}
+	}
+
+	var first, found frame
+	var ok bool
+	l.walkFrames(pcs[:n], func(f frame) bool {
+		if first.file == "" {
+			first = f
+		}
+		if l.callerFilter == nil || !l.callerFilter(f.file) {
+			found, ok = f, true
+			return false
+		}
+		return true
+	})
+	if !ok {
+		found = first
+	}
+
+	return found
+}
+
+func (l *Logger) callerInfo(depth, flags int) Caller {
+	f := l.caller(depth + 1)
+	file := f.file
+	if l.sourceRoot != "" && strings.HasPrefix(file, l.sourceRoot) {
+		file = file[len(l.sourceRoot):]
+	}
+	if flags&log.Lshortfile != 0 {
+		if i := strings.LastIndexByte(file, '/'); i >= 0 {
+			file = file[i+1:]
+		}
+	}
+
+	function := f.function
+	if l.callerShortFunc {
+		function = shortFuncName(function)
+	}
+
+	return Caller{Function: function, File: file, Line: f.line}
+}
+
+// shortFuncName drops the package path and pointer receiver markers from a
+// function name as reported by the runtime.
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return receiverMarkers.Replace(name)
+}
+
+var receiverMarkers = strings.NewReplacer("(*", "", ")", "")
+
+func prependCaller(c Caller, text string) string {
+	if c.File == "" {
+		return text
+	}
+	return c.File + ":" + strconv.Itoa(c.Line) + ": " + text
+}
+
+// callerFields returns c as the fields formatters write it as.
+func callerFields(c Caller) []Field {
+	fields := make([]Field, 0, 3)
+	if c.Function != "" {
+		fields = append(fields, Field{Key: "func", Value: c.Function})
+	}
+	return append(fields, Field{Key: "file", Value: c.File}, Field{Key: "line", Value: c.Line})
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func newCallerTestLogger() (*Logger, chan Record) {
+	ch := make(chan Record, 1)
+	l := New(WithSilent(true), WithChannel(ch), WithLogFlags(log.Lshortfile))
+	return l, ch
+}
+
+func logHelper(l *Logger, msg string) int {
+	_, _, line, _ := runtime.Caller(0)
+	l.Debug(msg)
+	return line + 1
+}
+
+func checkCaller(t *testing.T, name string, r Record, line int) {
+	t.Helper()
+	if r.Caller.File != "caller_test.go" || r.Caller.Line != line {
+		t.Errorf("%s caller = %s:%d, want caller_test.go:%d", name, r.Caller.File, r.Caller.Line, line)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	l, ch := newCallerTestLogger()
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Debug("direct")
+	checkCaller(t, "Debug", <-ch, line+1)
+
+	line = logHelper(l, "depth 0")
+	checkCaller(t, "SetDepth(0)", <-ch, line)
+
+	l.SetDepth(1)
+	_, _, line, _ = runtime.Caller(0)
+	logHelper(l, "depth 1")
+	checkCaller(t, "SetDepth(1)", <-ch, line+1)
+}
+
+func TestWriterOmitsCaller(t *testing.T) {
+	l, ch := newCallerTestLogger()
+
+	fmt.Fprintln(l.Writer(Info), "from a writer")
+	if got := (<-ch).Caller; got != (Caller{}) {
+		t.Errorf("Writer caller = %+v, want none", got)
+	}
+}
+
+func BenchmarkCaller(b *testing.B) {
+	for _, cache := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cache=%v", cache), func(b *testing.B) {
+			l := New(WithSilent(true), WithLogFlags(log.Lshortfile), WithCallerCache(cache))
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Info("hot call site")
+			}
+		})
+	}
+}
+
+// inlinedLog is small enough for the compiler to inline into its callers.
+// It logs on the line after its declaration.
+func inlinedLog(l *Logger) {
+	l.Info("inlined")
+}
+
+func TestCallerInlined(t *testing.T) {
+	l, ch := newCallerTestLogger()
+	fn := runtime.FuncForPC(reflect.ValueOf(inlinedLog).Pointer())
+	_, decl := fn.FileLine(fn.Entry())
+
+	inlinedLog(l)
+	checkCaller(t, "inlined", <-ch, decl+1)
+
+	l.SetDepth(1)
+	_, _, line, _ := runtime.Caller(0)
+	inlinedLog(l)
+	checkCaller(t, "inlined SetDepth(1)", <-ch, line+1)
+}
+
+type shortFuncReceiver struct {
+	l *Logger
+}
+
+func (r *shortFuncReceiver) log() {
+	r.l.Info("method")
+}
+
+func TestCallerShortFunc(t *testing.T) {
+	ch := make(chan Record, 1)
+	l := New(WithSilent(true), WithChannel(ch), WithLogFlags(log.Lshortfile), WithCallerShortFunc(true))
+
+	(&shortFuncReceiver{l: l}).log()
+	if got, want := (<-ch).Caller.Function, "shortFuncReceiver.log"; got != want {
+		t.Errorf("Function = %q, want %q", got, want)
+	}
+
+	l.Info("function")
+	if got, want := (<-ch).Caller.Function, "TestCallerShortFunc"; got != want {
+		t.Errorf("Function = %q, want %q", got, want)
+	}
+}
+
+func TestShortFuncName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"github.com/me/app/internal/server.(*Handler).ServeHTTP", "Handler.ServeHTTP"},
+		{"github.com/me/app/internal/server.Handler.ServeHTTP", "Handler.ServeHTTP"},
+		{"main.main.func1", "main.func1"},
+		{"main.run", "run"},
+	}
+	for _, tt := range tests {
+		if got := shortFuncName(tt.name); got != tt.want {
+			t.Errorf("shortFuncName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
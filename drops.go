@@ -0,0 +1,68 @@
+package logger
+
+import "sync/atomic"
+
+type dropReason int
+
+const (
+	dropSampled dropReason = iota
+	dropRateLimited
+	dropQueueFull
+	dropRepeated
+	dropSuppressed
+	dropReasons
+)
+
+// DropStats counts dropped records. Kept is not a drop: it counts the
+// records that percent sampling let through at a sampled level, so that
+// together with Sampled it gives the effective sampling rate.
+type DropStats struct {
+	Sampled      uint64
+	Kept         uint64
+	RateLimited  uint64
+	QueueFull    uint64
+	Repeated     uint64
+	Suppressed   uint64
+	WriteTimeout uint64
+	ByLevel      map[Level]uint64
+}
+
+func (l *Logger) drop(level Level, reason dropReason) {
+	atomic.AddUint64(&l.dropped[level][reason], 1)
+	// A record dropped before it was written still uses up a sequence
+	// number. A full channel means the record was written already.
+	if l.sequenceNumbers && reason != dropQueueFull {
+		atomic.AddUint64(&l.seq, 1)
+	}
+}
+
+// DroppedCounts returns the number of records dropped so far by reason and
+// by level. Write timeouts are counted per write, not per level.
+func (l *Logger) DroppedCounts() DropStats {
+	stats := DropStats{
+		WriteTimeout: l.WriteTimeouts(),
+		ByLevel:      make(map[Level]uint64),
+	}
+
+	for level := Debug; level <= Fatal; level++ {
+		sampled := atomic.LoadUint64(&l.dropped[level][dropSampled])
+		rateLimited := atomic.LoadUint64(&l.dropped[level][dropRateLimited])
+		queueFull := atomic.LoadUint64(&l.dropped[level][dropQueueFull])
+		repeated := atomic.LoadUint64(&l.dropped[level][dropRepeated])
+		suppressed := atomic.LoadUint64(&l.dropped[level][dropSuppressed])
+
+		stats.Sampled += sampled
+		stats.RateLimited += rateLimited
+		stats.QueueFull += queueFull
+		stats.Repeated += repeated
+		stats.Suppressed += suppressed
+		if l.percentSampler != nil {
+			stats.Kept += atomic.LoadUint64(&l.percentSampler.kept[level])
+		}
+		if n := sampled + rateLimited + queueFull + repeated + suppressed; n > 0 {
+			stats.ByLevel[level] = n
+		}
+	}
+
+	return stats
+}
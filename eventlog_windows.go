@@ -0,0 +1,92 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+type eventLogWriter struct {
+	handle  uintptr
+	eventID uint32
+}
+
+// NewEventLogWriter returns a writer that reports each line to the Windows
+// Event Log under source, mapping the level of the line to an event type.
+// The source should be registered beforehand, or the viewer shows the
+// messages with a warning about a missing description.
+func NewEventLogWriter(source string, eventID uint32) (io.WriteCloser, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return nil, err
+	}
+
+	return &eventLogWriter{handle: h, eventID: eventID}, nil
+}
+
+func eventLogType(level Level) uint16 {
+	switch level {
+	case Warn:
+		return eventlogWarningType
+	case Error, Fatal:
+		return eventlogErrorType
+	}
+	return eventlogInformationType
+}
+
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	level, msg, _ := lineLevel(p)
+	s, err := syscall.UTF16PtrFromString(string(bytes.TrimSuffix(msg, []byte("\n"))))
+	if err != nil {
+		return 0, err
+	}
+
+	ok, _, err := procReportEventW.Call(
+		w.handle,
+		uintptr(eventLogType(level)),
+		0,
+		uintptr(w.eventID),
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&s)),
+		0,
+	)
+	if ok == 0 {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *eventLogWriter) Close() error {
+	if ok, _, err := procDeregisterEventSource.Call(w.handle); ok == 0 {
+		return err
+	}
+	return nil
+}
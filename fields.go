@@ -0,0 +1,359 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const badKey = "!BADKEY"
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Valuer is a field value computed only when the record is written.
+type Valuer interface {
+	Value() interface{}
+}
+
+// ValuerFunc adapts a function to a Valuer.
+type ValuerFunc func() interface{}
+
+func (f ValuerFunc) Value() interface{} {
+	return f()
+}
+
+const maxValuerDepth = 100
+
+func resolveFields(fields []Field) []Field {
+	var resolved []Field
+	for i, f := range fields {
+		v, ok := f.Value.(Valuer)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]Field(nil), fields...)
+		}
+		value := v.Value()
+		for depth := 1; depth < maxValuerDepth; depth++ {
+			v, ok := value.(Valuer)
+			if !ok {
+				break
+			}
+			value = v.Value()
+		}
+		resolved[i].Value = value
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func fieldsFrom(keyvals []interface{}) []Field {
+	if len(keyvals) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i++ {
+		key, ok := keyvals[i].(string)
+		if !ok || i+1 == len(keyvals) {
+			fields = append(fields, Field{Key: badKey, Value: keyvals[i]})
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
+		i++
+	}
+
+	return fields
+}
+
+func replaceFields(fields []Field, replace func(key string, value interface{}) (string, interface{}, bool)) []Field {
+	replaced := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		if key, value, ok := replace(f.Key, f.Value); ok {
+			replaced = append(replaced, Field{Key: key, Value: value})
+		}
+	}
+	return replaced
+}
+
+func truncateFields(fields []Field, n int) []Field {
+	truncated := make([]Field, len(fields))
+	for i, f := range fields {
+		truncated[i] = f
+		s, ok := f.Value.(string)
+		if !ok {
+			if err, isErr := f.Value.(error); isErr {
+				s = err.Error()
+			} else {
+				s = fmt.Sprint(f.Value)
+			}
+		}
+		if len(s) > n {
+			truncated[i].Value = truncate(s, n)
+		}
+	}
+	return truncated
+}
+
+func sanitize(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sanitizeFields(fields []Field) []Field {
+	sanitized := make([]Field, len(fields))
+	for i, f := range fields {
+		sanitized[i] = f
+		sanitized[i].Key = sanitize(f.Key)
+		switch v := f.Value.(type) {
+		case string:
+			sanitized[i].Value = sanitize(v)
+		case error:
+			if s := v.Error(); strings.IndexFunc(s, unicode.IsControl) >= 0 {
+				sanitized[i].Value = sanitize(s)
+			}
+		}
+	}
+	return sanitized
+}
+
+func hasField(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupFields(fields []Field) []Field {
+	index := make(map[string]int, len(fields))
+	deduped := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		if i, ok := index[f.Key]; ok {
+			deduped[i].Value = f.Value
+			continue
+		}
+		index[f.Key] = len(deduped)
+		deduped = append(deduped, f)
+	}
+	return deduped
+}
+
+func orderFields(fields []Field, order []string) []Field {
+	ordered := make([]Field, 0, len(fields))
+	for _, key := range order {
+		for _, f := range fields {
+			if f.Key == key {
+				ordered = append(ordered, f)
+			}
+		}
+	}
+	for _, f := range fields {
+		pinned := false
+		for _, key := range order {
+			if f.Key == key {
+				pinned = true
+				break
+			}
+		}
+		if !pinned {
+			ordered = append(ordered, f)
+		}
+	}
+
+	return ordered
+}
+
+func appendFields(text string, fields []Field) string {
+	if len(fields) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(text, "\n"))
+	for _, f := range fields {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(formatValue(f.Value))
+	}
+
+	return b.String()
+}
+
+func formatValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if needsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		switch {
+		case r == ' ', r == '=', r == '"', r == '\\':
+			return true
+		case unicode.IsControl(r), r == utf8.RuneError:
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logger) DebugKV(msg string, keyvals ...interface{}) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, msg, fieldsFrom(keyvals))
+}
+
+func (l *Logger) InfoKV(msg string, keyvals ...interface{}) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, msg, fieldsFrom(keyvals))
+}
+
+func (l *Logger) WarnKV(msg string, keyvals ...interface{}) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, msg, fieldsFrom(keyvals))
+}
+
+func (l *Logger) ErrorKV(msg string, keyvals ...interface{}) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, msg, fieldsFrom(keyvals))
+}
+
+func (l *Logger) FatalKV(msg string, keyvals ...interface{}) {
+	l.log(Fatal, msg, fieldsFrom(keyvals))
+	l.exit(l.exitCode, msg)
+}
+
+func (l *Logger) DebugFields(msg string, fields ...Field) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, msg, fields[:len(fields):len(fields)])
+}
+
+func (l *Logger) InfoFields(msg string, fields ...Field) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, msg, fields[:len(fields):len(fields)])
+}
+
+func (l *Logger) WarnFields(msg string, fields ...Field) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, msg, fields[:len(fields):len(fields)])
+}
+
+func (l *Logger) ErrorFields(msg string, fields ...Field) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, msg, fields[:len(fields):len(fields)])
+}
+
+func (l *Logger) FatalFields(msg string, fields ...Field) {
+	l.log(Fatal, msg, fields[:len(fields):len(fields)])
+	l.exit(l.exitCode, msg)
+}
+
+func flattenErrors(errs []error) []error {
+	var flat []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			flat = append(flat, flattenErrors(joined.Unwrap())...)
+			continue
+		}
+		flat = append(flat, err)
+	}
+	return flat
+}
+
+func (l *Logger) Errors(msg string, errs ...error) {
+	if !l.enabled(Error) {
+		return
+	}
+
+	flat := flattenErrors(errs)
+	fields := make([]Field, len(flat))
+	for i, err := range flat {
+		fields[i] = Field{Key: "error." + strconv.Itoa(i), Value: err}
+	}
+	l.log(Error, msg, fields)
+}
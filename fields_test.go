@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestNeedsQuote(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"plain", false},
+		{"with space", true},
+		{"a=b", true},
+		{`he said "hi"`, true},
+		{`back\slash`, true},
+		{"line1\nline2", true},
+		{"tab\there", true},
+		{"\xff", true},
+		{"héllo", false},
+	}
+	for _, tt := range tests {
+		if got := needsQuote(tt.s); got != tt.want {
+			t.Errorf("needsQuote(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{"plain", "plain"},
+		{42, "42"},
+		{"", `""`},
+		{`he said "hi"`, `"he said \"hi\""`},
+		{`C:\temp`, `"C:\\temp"`},
+		{"line1\nline2\r\n", `"line1\nline2\r\n"`},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.v); got != tt.want {
+			t.Errorf("formatValue(%q) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceField(t *testing.T) {
+	ch := make(chan Record, 1)
+	l := New(
+		WithSilent(true),
+		WithChannel(ch),
+		WithFormatter(JSONFormatter{}),
+		WithLogFlags(log.Lshortfile),
+		WithFields("service", "api"),
+		WithReplaceField(func(key string, value interface{}) (string, interface{}, bool) {
+			switch key {
+			case "password", "func":
+				return "", nil, false
+			case "line":
+				return "lineno", value, true
+			case "service":
+				return "svc", value, true
+			}
+			return key, value, true
+		}),
+	)
+	l.InfoKV("login", "user", "bob", "password", "secret")
+
+	r := <-ch
+	if r.Caller != (Caller{}) {
+		t.Errorf("Caller = %+v, want it moved into the fields", r.Caller)
+	}
+	var keys []string
+	for _, f := range r.Fields {
+		keys = append(keys, f.Key)
+	}
+	if got, want := strings.Join(keys, ","), "file,lineno,svc,user"; got != want {
+		t.Errorf("field keys = %s, want %s", got, want)
+	}
+}
+
+func TestReplaceFieldDropsValuerUnresolved(t *testing.T) {
+	calls := 0
+	l := New(
+		WithSilent(true),
+		WithReplaceField(func(key string, value interface{}) (string, interface{}, bool) {
+			return key, value, key != "secret"
+		}),
+	)
+	l.InfoKV("msg", "secret", ValuerFunc(func() interface{} {
+		calls++
+		return "computed"
+	}))
+
+	if calls != 0 {
+		t.Errorf("dropped Valuer computed %d times", calls)
+	}
+}
+
+func TestReplaceFieldSeesSchemaVersion(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(
+		discardStdout(),
+		WithTee(&buf, JSONFormatter{}, Debug),
+		WithSchemaVersion("2"),
+		WithoutTimestamp(),
+		WithLogFlags(0),
+		WithReplaceField(func(key string, value interface{}) (string, interface{}, bool) {
+			if key == "schema_version" {
+				return "v", value, true
+			}
+			return key, value, true
+		}),
+	)
+	l.Info("hello")
+
+	if got, want := buf.String(), `{"level":"INFO","msg":"hello","v":"2"}`+"\n"; got != want {
+		t.Errorf("tee = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeControlCharsInKeys(t *testing.T) {
+	f := &memFile{}
+	l := New(WithLogFlags(0), WithInfoLogFile(f), WithSanitizeControlChars(true), discardStdout())
+	l.InfoKV("m", "evil\nINFO : forged", 1)
+
+	if got, want := f.String(), `INFO : m evil\nINFO : forged=1`+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
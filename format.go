@@ -0,0 +1,325 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Formatter interface {
+	Format(r Record) ([]byte, error)
+}
+
+// formatterName names formatter the way WithEncoder does.
+func formatterName(formatter Formatter) string {
+	switch f := formatter.(type) {
+	case nil, TextFormatter:
+		return "text"
+	case JSONFormatter:
+		if f.Pretty {
+			return "pretty"
+		}
+		return "json"
+	case LogfmtFormatter:
+		return "logfmt"
+	}
+	return fmt.Sprintf("%T", formatter)
+}
+
+// TextFormatter writes a record in the layout of the built-in text output:
+// the level tag, the date and time, the caller, the message and the fields.
+// Flags selects the date and time parts with the log package flags, those
+// of New when zero. Records without a time have none.
+type TextFormatter struct {
+	Flags int
+}
+
+func (f TextFormatter) Format(r Record) ([]byte, error) {
+	flags := f.Flags
+	if flags == 0 {
+		flags = defaultLogFlags
+	}
+
+	b := make([]byte, 0, 128)
+	if r.Level >= Debug && r.Level <= Fatal {
+		b = append(b, levelTags[r.Level]...)
+	}
+	if !r.Time.IsZero() {
+		b = appendLogTime(b, r.Time, flags)
+	}
+	b = append(b, prependCaller(r.Caller, appendFields(r.Message, r.Fields))...)
+
+	return append(b, '\n'), nil
+}
+
+// appendLogTime appends t the way log.Logger writes it for flags.
+func appendLogTime(b []byte, t time.Time, flags int) []byte {
+	if flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+	if flags&log.Ldate != 0 {
+		b = t.AppendFormat(b, "2006/01/02 ")
+	}
+	switch {
+	case flags&log.Lmicroseconds != 0:
+		b = t.AppendFormat(b, "15:04:05.000000 ")
+	case flags&log.Ltime != 0:
+		b = t.AppendFormat(b, "15:04:05 ")
+	}
+	return b
+}
+
+// JSONFormatter writes a record as a JSON object. MessageKey names the
+// message field, "msg" when empty; records with an empty message omit it.
+type JSONFormatter struct {
+	Pretty     bool
+	MessageKey string
+}
+
+var jsonBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func (f JSONFormatter) Format(r Record) ([]byte, error) {
+	b := jsonBufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	defer jsonBufferPool.Put(b)
+
+	b.WriteByte('{')
+	if !r.Time.IsZero() {
+		writeJSONField(b, "time", r.Time.Format(time.RFC3339Nano))
+	}
+	writeJSONField(b, "level", r.Level.String())
+	if r.Message != "" {
+		writeJSONField(b, messageKey(f.MessageKey), r.Message)
+	}
+	if r.Caller.File != "" {
+		if r.Caller.Function != "" {
+			writeJSONField(b, "func", r.Caller.Function)
+		}
+		writeJSONField(b, "file", r.Caller.File)
+		writeJSONField(b, "line", r.Caller.Line)
+	}
+	for _, field := range r.Fields {
+		writeJSONField(b, field.Key, field.Value)
+	}
+	b.WriteByte('}')
+
+	if !f.Pretty {
+		b.WriteByte('\n')
+		return append([]byte(nil), b.Bytes()...), nil
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, b.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	pretty.WriteByte('\n')
+
+	return pretty.Bytes(), nil
+}
+
+func writeJSONField(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+
+	writeJSONString(b, key)
+	b.WriteByte(':')
+
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	if writeJSONValue(b, value) {
+		return
+	}
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(v)
+}
+
+// writeJSONValue encodes the common value types without reflection, the
+// same way encoding/json does. It reports false for any other type.
+func writeJSONValue(b *bytes.Buffer, value interface{}) bool {
+	var scratch [64]byte
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		writeJSONString(b, v)
+	case bool:
+		b.Write(strconv.AppendBool(scratch[:0], v))
+	case int:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int8:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int16:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int32:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case uint:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint8:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint16:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint32:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		b.Write(strconv.AppendUint(scratch[:0], v, 10))
+	case float32:
+		return writeJSONFloat(b, float64(v), 32)
+	case float64:
+		return writeJSONFloat(b, v, 64)
+	case time.Duration:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case time.Time:
+		if y := v.Year(); y < 0 || y > 9999 {
+			return false
+		}
+		b.WriteByte('"')
+		b.Write(v.AppendFormat(scratch[:0], time.RFC3339Nano))
+		b.WriteByte('"')
+	default:
+		return false
+	}
+	return true
+}
+
+func writeJSONFloat(b *bytes.Buffer, f float64, bits int) bool {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return false
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	var scratch [32]byte
+	v := strconv.AppendFloat(scratch[:0], f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9, as encoding/json does.
+		if n := len(v); n >= 4 && v[n-4] == 'e' && v[n-3] == '-' && v[n-2] == '0' {
+			v[n-2] = v[n-1]
+			v = v[:n-1]
+		}
+	}
+	b.Write(v)
+
+	return true
+}
+
+const hexDigits = "0123456789abcdef"
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
+
+// LogfmtFormatter writes a record as logfmt key=value pairs. MessageKey
+// works as in JSONFormatter.
+type LogfmtFormatter struct {
+	MessageKey string
+}
+
+func messageKey(key string) string {
+	if key == "" {
+		return "msg"
+	}
+	return key
+}
+
+func (f LogfmtFormatter) Format(r Record) ([]byte, error) {
+	var b bytes.Buffer
+	if !r.Time.IsZero() {
+		writeLogfmtField(&b, "time", r.Time.Format(time.RFC3339Nano))
+	}
+	writeLogfmtField(&b, "level", r.Level.String())
+	if r.Message != "" {
+		writeLogfmtField(&b, messageKey(f.MessageKey), r.Message)
+	}
+	if r.Caller.File != "" {
+		if r.Caller.Function != "" {
+			writeLogfmtField(&b, "func", r.Caller.Function)
+		}
+		writeLogfmtField(&b, "file", r.Caller.File)
+		writeLogfmtField(&b, "line", r.Caller.Line)
+	}
+	for _, field := range r.Fields {
+		writeLogfmtField(&b, field.Key, field.Value)
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func writeLogfmtField(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(formatValue(value))
+}
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestPrettyJSONTogglesOnlyJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want Formatter
+	}{
+		{"pretty", []Option{WithFormatter(JSONFormatter{}), WithPrettyJSON(true)}, JSONFormatter{Pretty: true}},
+		{"compact", []Option{WithEncoder("pretty"), WithPrettyJSON(false)}, JSONFormatter{}},
+		{"logfmt", []Option{WithFormatter(LogfmtFormatter{}), WithPrettyJSON(true)}, LogfmtFormatter{}},
+		{"text", []Option{WithPrettyJSON(true)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(tt.opts)
+			if o.formatter != tt.want {
+				t.Errorf("formatter = %#v, want %#v", o.formatter, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogfmtFormatterQuoting(t *testing.T) {
+	r := Record{
+		Level:   Info,
+		Message: "multi\nline",
+		Fields: []Field{
+			{Key: "quote", Value: `he said "hi"`},
+			{Key: "plain", Value: "ok"},
+		},
+	}
+	b, err := LogfmtFormatter{}.Format(r)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	want := `level=INFO msg="multi\nline" quote="he said \"hi\"" plain=ok` + "\n"
+	if string(b) != want {
+		t.Errorf("Format = %q, want %q", b, want)
+	}
+}
+
+var jsonBenchValues = []interface{}{
+	"a string with \"quotes\" and <tags>",
+	42,
+	int64(-7),
+	uint32(7),
+	true,
+	3.14159,
+	1e-9,
+	1500 * time.Millisecond,
+	time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+}
+
+func TestJSONFastPathMatchesEncodingJSON(t *testing.T) {
+	for _, v := range jsonBenchValues {
+		var b bytes.Buffer
+		if !writeJSONValue(&b, v) {
+			t.Errorf("writeJSONValue(%#v) fell back to reflection", v)
+			continue
+		}
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal(%#v): %v", v, err)
+		}
+		if b.String() != string(want) {
+			t.Errorf("writeJSONValue(%#v) = %s, want %s", v, b.String(), want)
+		}
+	}
+}
+
+func BenchmarkJSONEncoder(b *testing.B) {
+	b.Run("fast", func(b *testing.B) {
+		var buf bytes.Buffer
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			for _, v := range jsonBenchValues {
+				writeJSONValue(&buf, v)
+			}
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		var buf bytes.Buffer
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			for _, v := range jsonBenchValues {
+				p, _ := json.Marshal(v)
+				buf.Write(p)
+			}
+		}
+	})
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	r := Record{
+		Time:    time.Now(),
+		Level:   Info,
+		Message: "request served",
+	}
+	for i, v := range jsonBenchValues {
+		r.Fields = append(r.Fields, Field{Key: string(rune('a' + i)), Value: v})
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		JSONFormatter{}.Format(r)
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	r := Record{Level: Info, Message: "hello", Fields: []Field{{Key: "k", Value: 1}}}
+	empty := Record{Level: Info, Fields: []Field{{Key: "k", Value: 1}}}
+	tests := []struct {
+		name string
+		opts []Option
+		r    Record
+		want string
+	}{
+		{"json default", []Option{WithFormatter(JSONFormatter{})}, r, `{"level":"INFO","msg":"hello","k":1}` + "\n"},
+		{"json renamed", []Option{WithFormatter(JSONFormatter{}), WithMessageKey("message")}, r, `{"level":"INFO","message":"hello","k":1}` + "\n"},
+		{"json empty", []Option{WithFormatter(JSONFormatter{})}, empty, `{"level":"INFO","k":1}` + "\n"},
+		{"logfmt renamed", []Option{WithFormatter(LogfmtFormatter{}), WithMessageKey("message")}, r, "level=INFO message=hello k=1\n"},
+		{"logfmt empty", []Option{WithFormatter(LogfmtFormatter{})}, empty, "level=INFO k=1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := newOptions(tt.opts).formatter.Format(tt.r)
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Format = %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextFormatterMatchesTextOutput(t *testing.T) {
+	var text, tee bytes.Buffer
+	l := New(
+		WithLogFlags(log.Lshortfile),
+		WithInfoLogFile(&text),
+		WithTee(&tee, TextFormatter{Flags: log.Lshortfile}, Debug),
+		discardStdout(),
+	)
+	l.InfoKV("hello", "k", "v w")
+
+	if tee.String() != text.String() {
+		t.Errorf("TextFormatter = %q, text output = %q", tee.String(), text.String())
+	}
+}
+
+func TestTextFormatterTime(t *testing.T) {
+	r := Record{Time: time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC), Level: Warn, Message: "hello"}
+	b, err := TextFormatter{}.Format(r)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	if got, want := string(b), "WARN : 2024/05/06 07:08:09.123456 hello\n"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+}
+
+func TestSeverityMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[Level]int
+		want    string
+	}{
+		{"default", nil, `{"level":"INFO","msg":"hello","severity":200}` + "\n"},
+		{"custom", map[Level]int{Info: 9}, `{"level":"INFO","msg":"hello","severity":9}` + "\n"},
+		{"missing", map[Level]int{Warn: 13}, `{"level":"INFO","msg":"hello"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &memFile{}
+			l := New(
+				WithFormatter(JSONFormatter{}),
+				WithoutTimestamp(),
+				WithLogFlags(0),
+				WithInfoLogFile(f),
+				discardStdout(),
+				WithSeverityMapping(tt.mapping),
+			)
+			l.Info("hello")
+
+			if got := f.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
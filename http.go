@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level, err := ParseLevel(string(body))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, l.Level())
+	})
+}
+
+func (l *Logger) HTTPRequest(r *http.Request, status int, duration time.Duration) {
+	level := Info
+	switch {
+	case status >= 500:
+		level = Error
+	case status >= 400:
+		level = Warn
+	}
+
+	if !l.enabled(level) {
+		return
+	}
+	l.log(level, "http request", []Field{
+		{Key: "method", Value: r.Method},
+		{Key: "path", Value: r.URL.Path},
+		{Key: "status", Value: status},
+		{Key: "duration", Value: duration},
+	})
+}
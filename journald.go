@@ -0,0 +1,89 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+type journaldWriter struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+func NewJournaldWriter(identifier string) (io.WriteCloser, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldWriter{
+		conn:       conn,
+		identifier: identifier,
+	}, nil
+}
+
+func journaldPriority(level Level) int {
+	switch level {
+	case Debug:
+		return 7
+	case Info:
+		return 6
+	case Warn:
+		return 4
+	case Error:
+		return 3
+	case Fatal:
+		return 2
+	}
+	return 6
+}
+
+func (w *journaldWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	level, msg, _ := lineLevel(p)
+
+	var b bytes.Buffer
+	writeJournaldField(&b, "PRIORITY", []byte(strconv.Itoa(journaldPriority(level))))
+	if w.identifier != "" {
+		writeJournaldField(&b, "SYSLOG_IDENTIFIER", []byte(w.identifier))
+	}
+	writeJournaldField(&b, "MESSAGE", bytes.TrimSuffix(msg, []byte("\n")))
+
+	if _, err := w.conn.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func writeJournaldField(b *bytes.Buffer, key string, value []byte) {
+	b.WriteString(key)
+	if bytes.IndexByte(value, '\n') < 0 {
+		b.WriteByte('=')
+		b.Write(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b.WriteByte('\n')
+	b.Write(size[:])
+	b.Write(value)
+	b.WriteByte('\n')
+}
+
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+type LatencyStats struct {
+	Count int64
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+}
+
+type latency struct {
+	mu    sync.Mutex
+	count int64
+	total time.Duration
+	min   time.Duration
+	max   time.Duration
+}
+
+func (l *latency) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.count == 0 || d < l.min {
+		l.min = d
+	}
+	if d > l.max {
+		l.max = d
+	}
+	l.count++
+	l.total += d
+}
+
+func (l *latency) stats() LatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := LatencyStats{
+		Count: l.count,
+		Min:   l.min,
+		Max:   l.max,
+	}
+	if l.count > 0 {
+		s.Mean = l.total / time.Duration(l.count)
+	}
+	return s
+}
+
+type latencies struct {
+	mu        sync.Mutex
+	latencies map[string]*latency
+}
+
+func (ls *latencies) wrap(name string, w io.Writer) io.Writer {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if ls.latencies == nil {
+		ls.latencies = make(map[string]*latency)
+	}
+	l, ok := ls.latencies[name]
+	if !ok {
+		l = &latency{}
+		ls.latencies[name] = l
+	}
+
+	return &latencyWriter{w: w, latency: l}
+}
+
+func (ls *latencies) stats() map[string]LatencyStats {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(ls.latencies))
+	for name, l := range ls.latencies {
+		stats[name] = l.stats()
+	}
+	return stats
+}
+
+type latencyWriter struct {
+	w       io.Writer
+	latency *latency
+}
+
+func (w *latencyWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.w.Write(p)
+	w.latency.observe(time.Since(start))
+	return n, err
+}
+
+func (l *Logger) WriteLatency() map[string]LatencyStats {
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
+
+	if l.latencies == nil {
+		return nil
+	}
+	return l.latencies.stats()
+}
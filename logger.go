@@ -1,12 +1,16 @@
 package logger
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 type Level int
@@ -19,6 +23,40 @@ const (
 	Fatal
 )
 
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "DEBUG"
+	case Info:
+		return "INFO"
+	case Warn:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	case Fatal:
+		return "FATAL"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+func (l Level) IsAtLeast(other Level) bool {
+	return l >= other
+}
+
+func Levels() []Level {
+	return []Level{Debug, Info, Warn, Error, Fatal}
+}
+
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	for level := Debug; level <= Fatal; level++ {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level %q", s)
+}
+
 const (
 	tagDebug = "DEBUG: "
 	tagInfo  = "INFO : "
@@ -27,85 +65,433 @@ const (
 	tagFatal = "FATAL: "
 )
 
+var levelTags = [Fatal + 1]string{tagDebug, tagInfo, tagWarn, tagError, tagFatal}
+
+type tee struct {
+	w         io.Writer
+	formatter Formatter
+	minLevel  Level
+}
+
+type thresholdWriter struct {
+	w        io.Writer
+	minLevel Level
+}
+
+type levelHook struct {
+	minLevel Level
+	hook     func(Record)
+}
+
 type Logger struct {
+	dropped [Fatal + 1][dropReasons]uint64
+	seq     uint64
+
+	opts options
+	ws   *WriterSet
+
+	// shared is set once a Sub logger writes through ws. Reconfigure then
+	// keeps the replaced writers open in retired until Close.
+	shared  uint32
+	retired []io.Closer
+
 	debugLog *log.Logger
 	infoLog  *log.Logger
 	warnLog  *log.Logger
 	errorLog *log.Logger
 	fatalLog *log.Logger
 
-	level Level
-	depth int
+	levels           uint32
+	printLevel       Level
+	maxMessageBytes  int
+	fieldValueMaxLen int
+	replaceField     func(key string, value interface{}) (string, interface{}, bool)
+	sanitize         bool
+	decimalBytes     bool
+	depth            int
+	flags            [Fatal + 1]int
+	callerFilter     func(file string) bool
+	sourceRoot       string
+	callerCache      *callerCache
+	callerMinLevel   Level
+	callerShortFunc  bool
+	fields           []Field
+	fatalPanics      bool
+	fieldOrder       []string
+	dedupFields      bool
+	prefix           string
+	limiters         [Fatal + 1]*rateLimiter
+	lineLimiter      *rateLimiter
+	sampler          *sampler
+	percentSampler   *percentSampler
+	once             *onceFilter
+	suppress         *suppressor
+	accessFormat     AccessFormat
+	skipEmpty        bool
+	onFatal          []func()
+	exitCode         int
+	exitFlushTimeout time.Duration
+	channel          chan<- Record
+	formatter        Formatter
+	tees             []tee
+	latencies        *latencies
+	writeTimeouts    *uint64
+	silent           bool
+	lockFree         bool
+	quietClose       bool
+	uptimeField      bool
+	sequenceNumbers  bool
+	schemaVersion    string
+	severities       map[Level]int
+	noTimestamp      bool
+	start            atomic.Value
+	runtimeStats     *runtimeStats
 
-	mu sync.Mutex
+	cfg sync.RWMutex
+	mu  sync.Mutex
 
+	levelHooks []levelHook
+
+	writers []io.Writer
 	closers []io.Closer
 }
 
 const defaultLogFlags = log.Ldate | log.Lmicroseconds | log.Lshortfile
 
+const defaultExitFlushTimeout = 5 * time.Second
+
 var DefaultLevel = Debug
 
-func New(opts ...Option) *Logger {
+func newOptions(opts []Option) options {
 	o := options{
-		level:    DefaultLevel,
-		logFlags: defaultLogFlags,
+		level:            DefaultLevel,
+		printLevel:       Info,
+		exitCode:         1,
+		exitFlushTimeout: defaultExitFlushTimeout,
+		logFlags:         defaultLogFlags,
 	}
+	o.applyAll(opts)
+
+	return o
+}
+
+func (o *options) applyAll(opts []Option) {
 	for _, opt := range opts {
-		opt.apply(&o)
+		opt.apply(o)
+	}
+	if o.withoutTimestamp {
+		o.logFlags &^= log.Ldate | log.Ltime | log.Lmicroseconds
+		for level, flags := range o.levelFlags {
+			o.levelFlags[level] = flags &^ (log.Ldate | log.Ltime | log.Lmicroseconds)
+		}
+	}
+	if o.fileHeader {
+		for _, f := range o.created {
+			writeFileHeader(f)
+		}
+	}
+	o.created = nil
+}
+
+func (o *options) levelTags() [Fatal + 1]string {
+	if !o.alignedLevels && o.tagSeparator == nil {
+		return levelTags
+	}
+
+	sep := " "
+	if o.tagSeparator != nil {
+		sep = *o.tagSeparator
 	}
 
-	var outputs []io.Writer
+	var tags [Fatal + 1]string
+	for level := Debug; level <= Fatal; level++ {
+		name := level.String()
+		if o.alignedLevels {
+			name = fmt.Sprintf("%-5s", name)
+		}
+		tags[level] = name + sep
+	}
+	return tags
+}
 
-	iLogs := []io.Writer{os.Stdout}
-	eLogs := []io.Writer{os.Stderr}
+func (o *options) flags(level Level) int {
+	if flags, ok := o.levelFlags[level]; ok {
+		return flags
+	}
+	return o.logFlags
+}
 
-	if o.infoLogFile != nil {
-		iLogs = append(iLogs, o.infoLogFile)
-		outputs = append(outputs, o.infoLogFile)
+func New(opts ...Option) *Logger {
+	o := newOptions(opts)
+	if o.err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logger: %v\n", o.err)
 	}
-	if o.errorLogFile != nil {
-		eLogs = append(eLogs, o.errorLogFile)
-		outputs = append(outputs, o.errorLogFile)
+
+	return newLogger(o)
+}
+
+func NewWithError(opts ...Option) (*Logger, error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		for _, c := range o.opened {
+			c.Close()
+		}
+		return nil, o.err
 	}
 
+	return newLogger(o), nil
+}
+
+func newLogger(o options) *Logger {
 	l := &Logger{
-		debugLog: log.New(io.MultiWriter(iLogs...), tagDebug, o.logFlags),
-		infoLog:  log.New(io.MultiWriter(iLogs...), tagInfo, o.logFlags),
-		warnLog:  log.New(io.MultiWriter(eLogs...), tagWarn, o.logFlags),
-		errorLog: log.New(io.MultiWriter(eLogs...), tagError, o.logFlags),
-		fatalLog: log.New(io.MultiWriter(eLogs...), tagFatal, o.logFlags),
-		level:    o.level,
+		opts:             o,
+		levels:           levelsOf(&o),
+		printLevel:       o.printLevel,
+		maxMessageBytes:  o.maxMessageBytes,
+		fieldValueMaxLen: o.fieldValueMaxLen,
+		replaceField:     o.replaceField,
+		sanitize:         o.sanitize,
+		decimalBytes:     o.decimalBytes,
+		callerFilter:     o.callerFilter,
+		callerMinLevel:   o.callerMinLevel,
+		callerShortFunc:  o.callerShortFunc,
+		fields:           o.fields,
+		fatalPanics:      o.fatalPanics,
+		fieldOrder:       o.fieldOrder,
+		dedupFields:      o.dedupFields,
+		prefix:           o.prefix,
+		skipEmpty:        o.skipEmpty,
+		onFatal:          o.onFatal,
+		exitCode:         o.exitCode,
+		exitFlushTimeout: o.exitFlushTimeout,
+		channel:          o.channel,
+		silent:           o.silent,
+		lockFree:         o.lockFree,
+		quietClose:       o.quietClose,
+		uptimeField:      o.uptimeField,
+		sequenceNumbers:  o.sequenceNumbers,
+		schemaVersion:    o.schemaVersion,
+		severities:       o.severities,
 	}
+	l.start.Store(time.Now())
+	l.setOutput(&o)
 
-	for _, output := range outputs {
-		if c, ok := output.(io.Closer); ok {
-			l.closers = append(l.closers, c)
+	if o.maxLineRate > 0 {
+		l.lineLimiter = newRateLimiter(o.maxLineRate, o.maxLineRate)
+	}
+
+	for level, limit := range o.rateLimits {
+		if levelBit(level) != 0 {
+			l.limiters[level] = newRateLimiter(limit.perSecond, limit.burst)
+		}
+	}
+
+	l.once = o.once
+	if o.suppress != nil {
+		l.suppress = &suppressor{level: o.suppress.level, capacity: o.suppress.capacity}
+		if l.suppress.capacity <= 0 {
+			l.suppress.capacity = defaultSuppressCapacity
+		}
+	}
+
+	if o.burstSampler != nil {
+		l.sampler = newSampler(o.burstSampler.first, o.burstSampler.thereafter)
+	}
+	if len(o.samplePercents) > 0 {
+		l.percentSampler = newPercentSampler(o.samplePercents)
+	}
+
+	if o.callerCache {
+		l.callerCache = &callerCache{}
+	}
+	if o.sourceRoot != "" {
+		l.sourceRoot = strings.TrimSuffix(o.sourceRoot, "/") + "/"
+	}
+
+	if o.runtimeStats > 0 {
+		l.startRuntimeStats(o.runtimeStats)
+	}
+
+	if o.startupBanner {
+		ws := l.ws
+		var names []string
+		for level := Debug; level <= Fatal; level++ {
+			if l.enabled(level) {
+				names = append(names, level.String())
+			}
 		}
+		l.output(Info, fmt.Sprintf("logger started: levels=%s info_outputs=%s error_outputs=%s format=%s flags=%s",
+			strings.Join(names, ","),
+			strings.Join(ws.infoOutputs, ","),
+			strings.Join(ws.errorOutputs, ","),
+			formatterName(o.formatter),
+			flagsString(o.logFlags)), nil, false)
 	}
 
 	return l
 }
 
+func levelsOf(o *options) uint32 {
+	if o.enabledLevels == nil {
+		return levelsFrom(o.level)
+	}
+
+	var levels uint32
+	for _, level := range o.enabledLevels {
+		levels |= levelBit(level)
+	}
+	return levels
+}
+
+func (l *Logger) setOutput(o *options) {
+	ws := o.writerSet
+	if ws == nil {
+		ws = newWriterSet(o)
+	}
+
+	l.ws = ws
+	l.debugLog = ws.debugLog
+	l.infoLog = ws.infoLog
+	l.warnLog = ws.warnLog
+	l.errorLog = ws.errorLog
+	l.fatalLog = ws.fatalLog
+	l.formatter = o.formatter
+	l.accessFormat = o.accessFormat
+	l.noTimestamp = o.withoutTimestamp
+	l.latencies = ws.latencies
+	l.writeTimeouts = &ws.writeTimeouts
+
+	for level := Debug; level <= Fatal; level++ {
+		l.flags[level] = o.flags(level)
+	}
+
+	l.writers = ws.writers
+	l.closers = nil
+	if o.writerSet == nil {
+		l.closers = append(l.closers, ws.closers...)
+	}
+	l.tees = append([]tee(nil), o.tees...)
+	names := make(map[string]int)
+	for i, t := range o.tees {
+		l.writers = append(l.writers[:len(l.writers):len(l.writers)], t.w)
+		if c, ok := t.w.(io.Closer); ok {
+			l.closers = append(l.closers, c)
+		}
+		if l.latencies != nil {
+			l.tees[i].w = l.latencies.wrap(uniqueName(names, "tee:"+writerName(t.w)), t.w)
+		}
+	}
+}
+
+func writerName(w io.Writer) string {
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
+
+func flagsString(flags int) string {
+	var names []string
+	for _, f := range []struct {
+		flag int
+		name string
+	}{
+		{log.Ldate, "date"},
+		{log.Ltime, "time"},
+		{log.Lmicroseconds, "microseconds"},
+		{log.Llongfile, "longfile"},
+		{log.Lshortfile, "shortfile"},
+		{log.LUTC, "utc"},
+	} {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
 func (l *Logger) Close() error {
+	if l.runtimeStats != nil {
+		l.runtimeStats.close()
+	}
+
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	var hasErr bool
-	for _, c := range l.closers {
+	return closeAll(append(l.retired[:len(l.retired):len(l.retired)], l.closers...), l.quietClose)
+}
+
+// CloseError is returned by Close when some destinations fail to close. It
+// holds one error per failed destination, each naming the destination.
+type CloseError struct {
+	Errors []error
+}
+
+func (e *CloseError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "failed to close some logs: " + strings.Join(msgs, "; ")
+}
+
+func (e *CloseError) Unwrap() []error {
+	return e.Errors
+}
+
+// closeAll closes closers in reverse order, so a closer registered after
+// the ones it writes to is closed first.
+func closeAll(closers []io.Closer, quiet bool) error {
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
 		if err := c.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to close log %v: %v\n", c, err)
-			hasErr = true
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Failed to close log %v: %v\n", c, err)
+			}
+			if _, ok := c.(*os.File); !ok {
+				err = fmt.Errorf("close %T: %w", c, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &CloseError{Errors: errs}
+	}
+
+	return nil
+}
+
+func (l *Logger) Verify() error {
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var failures []string
+	for _, w := range l.writers {
+		if _, err := w.Write(nil); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", writerName(w), err))
 		}
 	}
 
-	if hasErr {
-		return errors.New("failed to close some logs")
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to verify some logs: %s", strings.Join(failures, "; "))
 	}
 
 	return nil
 }
 
+func (l *Logger) Mark() {
+	l.start.Store(time.Now())
+}
+
 func (l *Logger) SetDepth(depth int) {
 	if depth < 0 {
 		panic("depth must be more than or equal to 0")
@@ -118,132 +504,1190 @@ func (l *Logger) Depth() int {
 	return l.depth
 }
 
-func (l *Logger) log(level Level, text string) {
-	if level < l.level {
-		return
+func levelBit(level Level) uint32 {
+	if level < Debug || level > Fatal {
+		return 0
 	}
+	return 1 << uint(level)
+}
 
-	l.mu.Lock()
-
-	switch level {
-	case Debug:
-		l.debugLog.Output(3+l.depth, text)
-	case Info:
-		l.infoLog.Output(3+l.depth, text)
-	case Warn:
-		l.warnLog.Output(3+l.depth, text)
-	case Error:
-		l.errorLog.Output(3+l.depth, text)
-	case Fatal:
-		l.fatalLog.Output(3+l.depth, text)
+func levelsFrom(min Level) uint32 {
+	var levels uint32
+	for level := Debug; level <= Fatal; level++ {
+		if level >= min {
+			levels |= levelBit(level)
+		}
 	}
+	return levels
+}
+
+func (l *Logger) SetPrefix(prefix string) {
+	l.cfg.Lock()
+	defer l.cfg.Unlock()
 
-	l.mu.Unlock()
+	l.prefix = prefix
+	l.opts.prefix = prefix
 }
 
-func (l *Logger) Debug(v ...interface{}) {
-	l.log(Debug, fmt.Sprint(v...))
+func (l *Logger) Prefix() string {
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
+
+	return l.prefix
 }
 
-func (l *Logger) Debugln(v ...interface{}) {
-	l.log(Debug, fmt.Sprintln(v...))
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreUint32(&l.levels, levelsFrom(level))
 }
 
-func (l *Logger) Debugf(format string, v ...interface{}) {
-	l.log(Debug, fmt.Sprintf(format, v...))
+func (l *Logger) Level() Level {
+	level := Debug
+	for ; level <= Fatal; level++ {
+		if l.enabled(level) {
+			break
+		}
+	}
+	return level
 }
 
-func (l *Logger) Info(v ...interface{}) {
-	l.log(Info, fmt.Sprint(v...))
+func (l *Logger) Enable(level Level) {
+	for {
+		old := atomic.LoadUint32(&l.levels)
+		if atomic.CompareAndSwapUint32(&l.levels, old, old|levelBit(level)) {
+			return
+		}
+	}
 }
 
-func (l *Logger) Infoln(v ...interface{}) {
-	l.log(Info, fmt.Sprintln(v...))
+func (l *Logger) Disable(level Level) {
+	for {
+		old := atomic.LoadUint32(&l.levels)
+		if atomic.CompareAndSwapUint32(&l.levels, old, old&^levelBit(level)) {
+			return
+		}
+	}
 }
 
-func (l *Logger) Infof(format string, v ...interface{}) {
-	l.log(Info, fmt.Sprintf(format, v...))
+func (l *Logger) enabled(level Level) bool {
+	bit := levelBit(level)
+	return bit != 0 && atomic.LoadUint32(&l.levels)&bit != 0
 }
 
-func (l *Logger) Warn(v ...interface{}) {
-	l.log(Warn, fmt.Sprint(v...))
+func (l *Logger) DebugEnabled() bool {
+	return l.enabled(Debug)
 }
 
-func (l *Logger) Warnln(v ...interface{}) {
-	l.log(Warn, fmt.Sprintln(v...))
+func (l *Logger) InfoEnabled() bool {
+	return l.enabled(Info)
 }
 
-func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.log(Warn, fmt.Sprintf(format, v...))
+func (l *Logger) WarnEnabled() bool {
+	return l.enabled(Warn)
 }
 
-func (l *Logger) Error(v ...interface{}) {
-	l.log(Error, fmt.Sprint(v...))
+func (l *Logger) ErrorEnabled() bool {
+	return l.enabled(Error)
 }
 
-func (l *Logger) Errorln(v ...interface{}) {
-	l.log(Error, fmt.Sprintln(v...))
+func (l *Logger) log(level Level, text string, fields []Field) {
+	l.output(level, text, fields, true)
 }
 
-func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.log(Error, fmt.Sprintf(format, v...))
+// output logs a record, resolving its caller when withCaller is set and the
+// flags ask for it. Lines fed through a Writer have no meaningful caller.
+func (l *Logger) output(level Level, text string, fields []Field, withCaller bool) {
+	if !l.enabled(level) {
+		return
+	}
+
+	if l.skipEmpty && len(fields) == 0 && strings.TrimSpace(text) == "" {
+		return
+	}
+
+	var dropped uint64
+	if limiter := l.limiters[level]; limiter != nil {
+		ok, n := limiter.allow(time.Now())
+		if !ok {
+			l.drop(level, dropRateLimited)
+			return
+		}
+		dropped += n
+	}
+	if l.lineLimiter != nil && level != Fatal {
+		ok, n := l.lineLimiter.allow(time.Now())
+		if !ok {
+			l.drop(level, dropRateLimited)
+			return
+		}
+		dropped += n
+	}
+	if dropped > 0 {
+		fields = append(fields, Field{Key: "dropped", Value: dropped})
+	}
+
+	if l.sampler != nil && level != Fatal {
+		ok, dropped := l.sampler.sample(level, text, time.Now())
+		if !ok {
+			l.drop(level, dropSampled)
+			return
+		}
+		if dropped > 0 {
+			fields = append(fields, Field{Key: "sampled", Value: dropped})
+		}
+	}
+
+	if l.percentSampler != nil && !l.percentSampler.keep(level) {
+		l.drop(level, dropSampled)
+		return
+	}
+
+	if l.maxMessageBytes > 0 {
+		text = truncate(text, l.maxMessageBytes)
+	}
+	if len(l.fields) > 0 {
+		fields = append(append(make([]Field, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+	}
+	if l.uptimeField {
+		fields = append(fields, Field{Key: "uptime", Value: time.Since(l.start.Load().(time.Time))})
+	}
+	if l.dedupFields && len(fields) > 1 {
+		fields = dedupFields(fields)
+	}
+	if l.replaceField != nil && len(fields) > 0 {
+		fields = replaceFields(fields, l.replaceField)
+	}
+	fields = resolveFields(fields)
+	if l.fieldValueMaxLen > 0 {
+		fields = truncateFields(fields, l.fieldValueMaxLen)
+	}
+	if l.sanitize {
+		text = sanitize(strings.TrimSuffix(text, "\n"))
+		fields = sanitizeFields(fields)
+	}
+	if len(l.fieldOrder) > 0 && len(fields) > 0 {
+		fields = orderFields(fields, l.fieldOrder)
+	}
+
+	l.cfg.RLock()
+
+	if l.prefix != "" {
+		text = l.prefix + text
+	}
+
+	r := Record{
+		Level:   level,
+		Message: strings.TrimSuffix(text, "\n"),
+		Fields:  fields,
+	}
+	if l.formatter != nil || l.channel != nil || len(l.tees) > 0 {
+		r.Time = time.Now()
+	}
+	if withCaller && level >= l.callerMinLevel && l.flags[level]&callerFlags != 0 && !hasField(fields, "caller") {
+		r.Caller = l.callerInfo(callDepth+l.depth, l.flags[level])
+		if l.replaceField != nil && l.formatter != nil {
+			r.Fields = append(replaceFields(callerFields(r.Caller), l.replaceField), r.Fields...)
+			r.Caller = Caller{}
+		}
+	}
+	if l.once != nil && level != Fatal && !l.once.first(r) {
+		l.cfg.RUnlock()
+		l.drop(level, dropRepeated)
+		return
+	}
+
+	if l.suppress == nil {
+		r = l.write(level, text, r)
+	} else {
+		held, flush := l.holdUntilReady(level, text, r)
+		l.writeHeld(flush)
+		if !held {
+			r = l.write(level, text, r)
+		}
+	}
+
+	hooks := l.levelHooks
+
+	l.cfg.RUnlock()
+
+	for _, h := range hooks {
+		if level >= h.minLevel {
+			if r.Time.IsZero() {
+				r.Time = time.Now()
+			}
+			h.hook(r)
+		}
+	}
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
-	l.log(Fatal, fmt.Sprint(v...))
-	l.Close()
-	os.Exit(1)
+// write writes a record to the destinations, the tees and the channel, and
+// returns it as written. Silent loggers skip the destinations and the tees.
+// The caller must hold l.cfg.
+func (l *Logger) write(level Level, text string, r Record) Record {
+	if !l.lockFree {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	// The sequence number is taken under l.mu so that it follows the order
+	// in which records reach the destinations.
+	if l.sequenceNumbers {
+		r.Fields = l.sequenceFields(r.Fields)
+	}
+
+	formatted := r
+	if (l.schemaVersion != "" || l.severities != nil) && (l.formatter != nil || len(l.tees) > 0) {
+		formatted.Fields = append(l.formattedFields(level), r.Fields...)
+	}
+
+	switch {
+	case l.silent:
+	case l.formatter != nil:
+		l.writeRecord(l.logger(level).Writer(), l.formatter, formatted)
+	default:
+		l.logger(level).Output(callDepth+l.depth, l.formatText(text, r))
+	}
+
+	if !l.silent {
+		for _, t := range l.tees {
+			if level >= t.minLevel {
+				l.writeRecord(t.w, t.formatter, formatted)
+			}
+		}
+	}
+
+	if l.channel != nil {
+		select {
+		case l.channel <- r:
+		default:
+			l.drop(level, dropQueueFull)
+		}
+	}
+
+	return r
 }
 
-func (l *Logger) Fatalln(v ...interface{}) {
-	l.log(Fatal, fmt.Sprintln(v...))
-	l.Close()
-	os.Exit(1)
+func (l *Logger) sequenceFields(fields []Field) []Field {
+	seq := []Field{{Key: "seq", Value: atomic.AddUint64(&l.seq, 1)}}
+	if l.replaceField != nil {
+		seq = replaceFields(seq, l.replaceField)
+	}
+	fields = append(seq, fields...)
+	if len(l.fieldOrder) > 0 {
+		fields = orderFields(fields, l.fieldOrder)
+	}
+	return fields
 }
 
-func (l *Logger) Fatalf(format string, v ...interface{}) {
-	l.log(Fatal, fmt.Sprintf(format, v...))
-	l.Close()
-	os.Exit(1)
+// formattedFields returns the schema_version and severity fields that
+// formatted output starts with.
+func (l *Logger) formattedFields(level Level) []Field {
+	var fields []Field
+	if l.schemaVersion != "" {
+		fields = append(fields, Field{Key: "schema_version", Value: l.schemaVersion})
+	}
+	if severity, ok := l.severities[level]; ok {
+		fields = append(fields, Field{Key: "severity", Value: severity})
+	}
+	if l.replaceField != nil {
+		fields = replaceFields(fields, l.replaceField)
+	}
+	return fields
 }
 
-type options struct {
-	level        Level
-	infoLogFile  io.Writer
-	errorLogFile io.Writer
-	logFlags     int
+// formatText renders the text output of a record: the caller, the message
+// and the fields.
+func (l *Logger) formatText(text string, r Record) string {
+	fields := r.Fields
+	if l.decimalBytes {
+		fields = decimalByteFields(fields)
+	}
+	return prependCaller(r.Caller, appendFields(text, fields))
 }
 
-type Option interface {
-	apply(o *options)
+func (l *Logger) AddLevelHook(minLevel Level, hook func(Record)) {
+	l.cfg.Lock()
+	defer l.cfg.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hooks := make([]levelHook, len(l.levelHooks), len(l.levelHooks)+1)
+	copy(hooks, l.levelHooks)
+	l.levelHooks = append(hooks, levelHook{minLevel: minLevel, hook: hook})
 }
 
-type OptionFunc func(o *options)
+func (l *Logger) WriteTimeouts() uint64 {
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
 
-func (f OptionFunc) apply(o *options) {
-	f(o)
+	return atomic.LoadUint64(l.writeTimeouts)
 }
 
-func WithLevel(level Level) Option {
-	return OptionFunc(func(o *options) {
-		o.level = level
-	})
+func (l *Logger) logger(level Level) *log.Logger {
+	switch level {
+	case Debug:
+		return l.debugLog
+	case Info:
+		return l.infoLog
+	case Warn:
+		return l.warnLog
+	case Error:
+		return l.errorLog
+	}
+	return l.fatalLog
 }
 
-func WithInfoLogFile(logFile io.Writer) Option {
-	return OptionFunc(func(o *options) {
-		o.infoLogFile = logFile
-	})
+func (l *Logger) writeRecord(w io.Writer, formatter Formatter, r Record) {
+	if l.noTimestamp {
+		r.Time = time.Time{}
+	}
+	b, err := formatter.Format(r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to format log record: %v\n", err)
+		return
+	}
+
+	w.Write(b)
 }
 
-func WithErrorLogFile(logFile io.Writer) Option {
-	return OptionFunc(func(o *options) {
-		o.errorLogFile = logFile
-	})
+func (l *Logger) ChannelDropped() uint64 {
+	return l.DroppedCounts().QueueFull
 }
 
-func WithLogFlags(flags int) Option {
-	return OptionFunc(func(o *options) {
-		o.logFlags = flags
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s... (truncated, %d bytes)", s[:cut], len(s))
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) Debugln(v ...interface{}) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, fmt.Sprintln(v...), nil)
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Info(v ...interface{}) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) Infoln(v ...interface{}) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, fmt.Sprintln(v...), nil)
+}
+
+func (l *Logger) Infof(format string, v ...interface{}) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Warn(v ...interface{}) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) Warnln(v ...interface{}) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, fmt.Sprintln(v...), nil)
+}
+
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) Errorln(v ...interface{}) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, fmt.Sprintln(v...), nil)
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Print(v ...interface{}) {
+	if !l.enabled(l.printLevel) {
+		return
+	}
+	l.log(l.printLevel, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) Println(v ...interface{}) {
+	if !l.enabled(l.printLevel) {
+		return
+	}
+	l.log(l.printLevel, fmt.Sprintln(v...), nil)
+}
+
+func (l *Logger) Printf(format string, v ...interface{}) {
+	if !l.enabled(l.printLevel) {
+		return
+	}
+	l.log(l.printLevel, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) exit(code int, msg string) {
+	for _, f := range l.onFatal {
+		runFatalCallback(f)
+	}
+
+	l.closeWithTimeout(l.exitFlushTimeout)
+	if l.fatalPanics {
+		panic(msg)
+	}
+	os.Exit(code)
+}
+
+func (l *Logger) closeWithTimeout(d time.Duration) {
+	if d <= 0 {
+		l.Close()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.Close()
+		close(done)
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		fmt.Fprintf(os.Stderr, "Timed out closing logs after %v\n", d)
+	}
+}
+
+func runFatalCallback(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "Fatal callback panicked: %v\n", r)
+		}
+	}()
+	f()
+}
+
+func (l *Logger) Fatal(v ...interface{}) {
+	text := fmt.Sprint(v...)
+	l.log(Fatal, text, nil)
+	l.exit(l.exitCode, text)
+}
+
+func (l *Logger) Fatalln(v ...interface{}) {
+	text := fmt.Sprintln(v...)
+	l.log(Fatal, text, nil)
+	l.exit(l.exitCode, text)
+}
+
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	text := fmt.Sprintf(format, v...)
+	l.log(Fatal, text, nil)
+	l.exit(l.exitCode, text)
+}
+
+func (l *Logger) FatalCode(code int, v ...interface{}) {
+	text := fmt.Sprint(v...)
+	l.log(Fatal, text, nil)
+	l.exit(code, text)
+}
+
+type options struct {
+	level            Level
+	enabledLevels    []Level
+	printLevel       Level
+	startupBanner    bool
+	maxMessageBytes  int
+	fieldValueMaxLen int
+	replaceField     func(key string, value interface{}) (string, interface{}, bool)
+	sanitize         bool
+	decimalBytes     bool
+	callerFilter     func(file string) bool
+	sourceRoot       string
+	callerCache      bool
+	callerMinLevel   Level
+	callerShortFunc  bool
+	fields           []Field
+	fatalPanics      bool
+	fieldOrder       []string
+	dedupFields      bool
+	prefix           string
+	rateLimits       map[Level]rateLimit
+	maxLineRate      int
+	burstSampler     *burstSampler
+	samplePercents   map[Level]float64
+	suppress         *suppressUntilReady
+	once             *onceFilter
+	accessFormat     AccessFormat
+	skipEmpty        bool
+	onFatal          []func()
+	exitCode         int
+	exitFlushTimeout time.Duration
+	channel          chan<- Record
+	formatter        Formatter
+	writerTimeout    time.Duration
+	middlewares      []func(io.Writer) io.Writer
+	silent           bool
+	lockFree         bool
+	quietClose       bool
+	uptimeField      bool
+	sequenceNumbers  bool
+	schemaVersion    string
+	severities       map[Level]int
+	runtimeStats     time.Duration
+	withoutTimestamp bool
+	writerSet        *WriterSet
+	tees             []tee
+	thresholdWriters []thresholdWriter
+	writeLatency     bool
+	infoLogFile      io.Writer
+	errorLogFile     io.Writer
+	logFlags         int
+	levelFlags       map[Level]int
+	alignedLevels    bool
+	tagSeparator     *string
+	opened           []io.Closer
+	created          []*os.File
+	fileHeader       bool
+	err              error
+}
+
+func (o *options) setErr(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+type rateLimit struct {
+	perSecond int
+	burst     int
+}
+
+type suppressUntilReady struct {
+	level    Level
+	capacity int
+}
+
+type burstSampler struct {
+	first      int
+	thereafter int
+}
+
+type Option interface {
+	apply(o *options)
+}
+
+type OptionFunc func(o *options)
+
+func (f OptionFunc) apply(o *options) {
+	f(o)
+}
+
+func WithLevel(level Level) Option {
+	return OptionFunc(func(o *options) {
+		o.level = level
+	})
+}
+
+func WithEnabledLevels(levels ...Level) Option {
+	return OptionFunc(func(o *options) {
+		o.enabledLevels = append([]Level{}, levels...)
+	})
+}
+
+func WithPrintLevel(level Level) Option {
+	return OptionFunc(func(o *options) {
+		o.printLevel = level
+	})
+}
+
+func WithStartupBanner(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.startupBanner = enabled
+	})
+}
+
+func WithMaxMessageBytes(n int) Option {
+	return OptionFunc(func(o *options) {
+		o.maxMessageBytes = n
+	})
+}
+
+func WithFieldValueMaxLen(n int) Option {
+	return OptionFunc(func(o *options) {
+		o.fieldValueMaxLen = n
+	})
+}
+
+// WithReplaceField calls replace for every field of every record, including
+// static and internal fields, before the fields are truncated or written. It
+// returns the key and value to write, or false to drop the field. A Valuer
+// is passed unresolved, so dropping it never computes its value. With a
+// formatter, the caller is passed through replace as the func, file and line
+// fields; text output keeps it as a prefix.
+func WithReplaceField(replace func(key string, value interface{}) (string, interface{}, bool)) Option {
+	return OptionFunc(func(o *options) {
+		o.replaceField = replace
+	})
+}
+
+// WithDecimalBytes writes Bytes fields in text output with decimal units,
+// such as 1.5MB, instead of binary ones.
+func WithDecimalBytes(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.decimalBytes = enabled
+	})
+}
+
+// WithSanitizeControlChars escapes control characters, including CR, LF and
+// ESC, in messages, field keys and string field values so untrusted input
+// cannot forge log lines or emit terminal escape sequences.
+func WithSanitizeControlChars(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.sanitize = enabled
+	})
+}
+
+func WithCallerFilter(filter func(file string) bool) Option {
+	return OptionFunc(func(o *options) {
+		o.callerFilter = filter
+	})
+}
+
+func WithSourceRoot(prefix string) Option {
+	return OptionFunc(func(o *options) {
+		o.sourceRoot = prefix
+	})
+}
+
+func WithCallerCache(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.callerCache = enabled
+	})
+}
+
+func WithCallerMinLevel(level Level) Option {
+	return OptionFunc(func(o *options) {
+		o.callerMinLevel = level
+	})
+}
+
+// WithCallerShortFunc trims the package path from the caller function that
+// formatters write as func, so "example.com/app/server.(*Handler).ServeHTTP"
+// becomes "Handler.ServeHTTP".
+func WithCallerShortFunc(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.callerShortFunc = enabled
+	})
+}
+
+func WithPrefix(prefix string) Option {
+	return OptionFunc(func(o *options) {
+		o.prefix = prefix
+	})
+}
+
+func WithFields(keyvals ...interface{}) Option {
+	return OptionFunc(func(o *options) {
+		o.fields = append(o.fields, fieldsFrom(keyvals)...)
+	})
+}
+
+// WithDeduplicateFields merges fields with the same key, from WithFields and
+// from the call, into one. The field keeps the position of the first one
+// and the value of the last one.
+func WithDeduplicateFields(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.dedupFields = enabled
+	})
+}
+
+func WithAttrsFromEnv(fields map[string]string) Option {
+	return OptionFunc(func(o *options) {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if value, ok := os.LookupEnv(fields[key]); ok {
+				o.fields = append(o.fields, Field{Key: key, Value: value})
+			}
+		}
+	})
+}
+
+func WithFieldOrder(keys []string) Option {
+	return OptionFunc(func(o *options) {
+		o.fieldOrder = append([]string{}, keys...)
+	})
+}
+
+func WithRateLimit(level Level, perSecond, burst int) Option {
+	return OptionFunc(func(o *options) {
+		if o.rateLimits == nil {
+			o.rateLimits = make(map[Level]rateLimit)
+		}
+		o.rateLimits[level] = rateLimit{perSecond: perSecond, burst: burst}
+	})
+}
+
+// WithPercentSampling writes a random percent of the records at level and
+// drops the rest, counting them as sampled. Fatal is never sampled.
+func WithPercentSampling(level Level, percent float64) Option {
+	return OptionFunc(func(o *options) {
+		if o.samplePercents == nil {
+			o.samplePercents = make(map[Level]float64)
+		}
+		o.samplePercents[level] = percent
+	})
+}
+
+// WithMaxLineRate caps the number of records written per second across all
+// levels. Fatal records are never dropped. The number of records dropped is
+// reported as a dropped field on the next one written.
+func WithMaxLineRate(perSecond int) Option {
+	return OptionFunc(func(o *options) {
+		o.maxLineRate = perSecond
+	})
+}
+
+// WithBurstSampler samples records per level and message: within each second
+// the first records of a message are written, and after that only every
+// thereafter-th one. The number of records dropped for a message is
+// reported as a sampled field on the next one written. Fatal is never
+// sampled.
+func WithBurstSampler(first, thereafter int) Option {
+	return OptionFunc(func(o *options) {
+		o.burstSampler = &burstSampler{first: first, thereafter: thereafter}
+	})
+}
+
+func WithAccessLogFormat(format AccessFormat) Option {
+	return OptionFunc(func(o *options) {
+		o.accessFormat = format
+	})
+}
+
+// WithLogOncePerKey logs only the first record for each key returned by
+// keyFunc and drops the repeats; records with an empty key and Fatal records
+// are always logged. At most capacity keys are remembered, 1024 if capacity
+// is not positive: the least recently seen key is forgotten beyond that, and
+// LogOnceEvictions counts how often.
+func WithLogOncePerKey(keyFunc func(Record) string, capacity int) Option {
+	return OptionFunc(func(o *options) {
+		o.once = newOnceFilter(keyFunc, capacity)
+	})
+}
+
+// WithSuppressUntilReady holds back records below level until Ready is
+// called. Ready writes the held records if an Error or Fatal record was
+// logged in the meantime and discards them otherwise. At most capacity
+// records are held, 1000 if capacity is not positive; the oldest are
+// dropped beyond that. A Fatal record writes
+// the held records before itself and ends the holding. Text output of held
+// records carries the time they are written, not the time they were logged.
+func WithSuppressUntilReady(level Level, capacity int) Option {
+	return OptionFunc(func(o *options) {
+		o.suppress = &suppressUntilReady{level: level, capacity: capacity}
+	})
+}
+
+func WithSkipEmpty(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.skipEmpty = enabled
+	})
+}
+
+// WithFatalPanics makes the Fatal methods panic with the message after
+// closing the logger, instead of calling os.Exit(1), so that a library
+// using the logger leaves termination to the host application.
+func WithFatalPanics(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.fatalPanics = enabled
+	})
+}
+
+// WithOnFatal registers f to run after a Fatal method logs its message and
+// before the logger is closed and the process exits. Callbacks run in the
+// order they were registered; a panicking callback is recovered so that
+// the remaining callbacks and the exit still happen.
+func WithOnFatal(f func()) Option {
+	return OptionFunc(func(o *options) {
+		o.onFatal = append(o.onFatal, f)
+	})
+}
+
+// WithChannel publishes every written record on ch as well. The send never
+// blocks: when ch is full the record is not published and is counted by
+// ChannelDropped instead.
+func WithChannel(ch chan<- Record) Option {
+	return OptionFunc(func(o *options) {
+		o.channel = ch
+	})
+}
+
+func WithExitCode(code int) Option {
+	return OptionFunc(func(o *options) {
+		o.exitCode = code
+	})
+}
+
+func WithFormatter(formatter Formatter) Option {
+	return OptionFunc(func(o *options) {
+		o.formatter = formatter
+	})
+}
+
+// WithWriterTimeout bounds every write to a destination by d. When a write
+// does not finish in time the line is dropped for that destination and
+// counted by WriteTimeouts; further lines for it are dropped until the
+// stuck write returns. A net.Conn is bounded with a write deadline.
+func WithWriterTimeout(d time.Duration) Option {
+	return OptionFunc(func(o *options) {
+		o.writerTimeout = d
+	})
+}
+
+// WithWriterMiddleware wraps every destination with mw. Middlewares compose
+// in the order they are given: the first one sees the bytes first and
+// the last one is closest to the destination. Wrappers that implement
+// io.Closer are closed by Close, outermost first, before the destination.
+func WithWriterMiddleware(mw func(io.Writer) io.Writer) Option {
+	return OptionFunc(func(o *options) {
+		o.middlewares = append(o.middlewares, mw)
+	})
+}
+
+func WithWriteLatency(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.writeLatency = enabled
+	})
+}
+
+// WithLockFreeWrites makes the logger write records without holding its
+// mutex. It is only safe when every destination and tee accepts concurrent
+// Write calls, such as an *os.File: each record is still passed in a single
+// Write, but records of different goroutines may reach the destinations in
+// different orders. With WithWriterTimeout, a record that arrives while
+// another is being written to the same destination is dropped.
+func WithLockFreeWrites(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.lockFree = enabled
+	})
+}
+
+// WithQuietClose stops Close from printing each close failure to stderr.
+// The failures are still returned in a *CloseError.
+func WithQuietClose(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.quietClose = enabled
+	})
+}
+
+func WithSilent(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.silent = enabled
+	})
+}
+
+func WithUptimeField(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.uptimeField = enabled
+	})
+}
+
+// WithSequenceNumbers adds a seq field, numbered from 1, to every record
+// written. Records dropped by sampling, rate limiting, deduplication or
+// suppression use up a number too, so gaps reveal them. Numbers follow the
+// write order, except with WithLockFreeWrites.
+func WithSequenceNumbers(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.sequenceNumbers = enabled
+	})
+}
+
+// WithRuntimeStats logs the number of goroutines, the heap allocation and
+// the GC count at Info every interval until Close is called.
+func WithRuntimeStats(interval time.Duration) Option {
+	return OptionFunc(func(o *options) {
+		o.runtimeStats = interval
+	})
+}
+
+// WithEncoder selects the output format by name: "text", "json", "pretty"
+// or "logfmt". An unknown name is reported as a configuration error.
+func WithEncoder(name string) Option {
+	return OptionFunc(func(o *options) {
+		switch name {
+		case "text":
+			o.formatter = nil
+		case "json":
+			o.formatter = JSONFormatter{}
+		case "pretty":
+			o.formatter = JSONFormatter{Pretty: true}
+		case "logfmt":
+			o.formatter = LogfmtFormatter{}
+		default:
+			o.setErr(fmt.Errorf("unknown encoder %q", name))
+		}
+	})
+}
+
+// WithSchemaVersion adds a schema_version field holding version to records
+// written by a formatter, including tees. Text output is unchanged.
+func WithSchemaVersion(version string) Option {
+	return OptionFunc(func(o *options) {
+		o.schemaVersion = version
+	})
+}
+
+// WithSeverityMapping adds a numeric severity field to records written by a
+// formatter, for log viewers such as Google Cloud Logging that filter on
+// it. A nil mapping uses the Cloud Logging scheme: Debug=100, Info=200,
+// Warn=400, Error=500 and Fatal=600. Levels missing from the mapping get no
+// field.
+func WithSeverityMapping(mapping map[Level]int) Option {
+	return OptionFunc(func(o *options) {
+		if mapping == nil {
+			mapping = cloudSeverities
+		}
+		severities := make(map[Level]int, len(mapping))
+		for level, severity := range mapping {
+			severities[level] = severity
+		}
+		o.severities = severities
+	})
+}
+
+var cloudSeverities = map[Level]int{Debug: 100, Info: 200, Warn: 400, Error: 500, Fatal: 600}
+
+// WithPrettyJSON switches the JSON formatter selected by an earlier option
+// between indented and compact output. Other formatters are left as they are.
+func WithPrettyJSON(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		if f, ok := o.formatter.(JSONFormatter); ok {
+			f.Pretty = enabled
+			o.formatter = f
+		}
+	})
+}
+
+// WithMessageKey renames the message field of the JSON or logfmt formatter
+// selected by an earlier option. Text output is unchanged.
+func WithMessageKey(key string) Option {
+	return OptionFunc(func(o *options) {
+		switch f := o.formatter.(type) {
+		case JSONFormatter:
+			f.MessageKey = key
+			o.formatter = f
+		case LogfmtFormatter:
+			f.MessageKey = key
+			o.formatter = f
+		}
+	})
+}
+
+// WithExitFlushTimeout bounds how long a Fatal method waits for the logger to
+// close before exiting. The default is 5 seconds; d <= 0 waits forever.
+func WithExitFlushTimeout(d time.Duration) Option {
+	return OptionFunc(func(o *options) {
+		o.exitFlushTimeout = d
+	})
+}
+
+// WithWriterSet makes the logger write to the destinations of ws instead of
+// building its own. The logger does not own them: Close on the logger leaves
+// them open, and ws.Close must be called once every logger using it is done.
+// Destination options such as WithInfoLogFile and WithLogFlags are taken
+// from ws and ignored on the logger, except for the caller flags.
+func WithWriterSet(ws *WriterSet) Option {
+	return OptionFunc(func(o *options) {
+		o.writerSet = ws
+	})
+}
+
+func WithTee(w io.Writer, formatter Formatter, minLevel Level) Option {
+	return OptionFunc(func(o *options) {
+		if formatter == nil {
+			o.setErr(fmt.Errorf("tee formatter is nil"))
+			return
+		}
+		o.tees = append(o.tees, tee{w: w, formatter: formatter, minLevel: minLevel})
+	})
+}
+
+func WithThresholdWriter(w io.Writer, minLevel Level) Option {
+	return OptionFunc(func(o *options) {
+		o.thresholdWriters = append(o.thresholdWriters, thresholdWriter{w: w, minLevel: minLevel})
+	})
+}
+
+func WithInfoLogFile(logFile io.Writer) Option {
+	return OptionFunc(func(o *options) {
+		o.infoLogFile = logFile
+	})
+}
+
+func WithErrorLogFile(logFile io.Writer) Option {
+	return OptionFunc(func(o *options) {
+		o.errorLogFile = logFile
+	})
+}
+
+func WithLogBasePath(base string) Option {
+	return OptionFunc(func(o *options) {
+		infoFile, err := o.openLogFile(base + ".info.log")
+		if err != nil {
+			o.setErr(err)
+			return
+		}
+		errorFile, err := o.openLogFile(base + ".error.log")
+		if err != nil {
+			infoFile.Close()
+			o.setErr(err)
+			return
+		}
+
+		o.infoLogFile = infoFile
+		o.errorLogFile = errorFile
+		o.opened = append(o.opened, infoFile, errorFile)
+	})
+}
+
+func (o *options) openLogFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0644)
+	if err == nil {
+		o.created = append(o.created, f)
+		return f, nil
+	}
+	if !os.IsExist(err) {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+func writeFileHeader(f *os.File) {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+
+	_, err = fmt.Fprintf(f, "# pid=%d start=%s exe=%s args=%q\n",
+		os.Getpid(),
+		time.Now().Format(time.RFC3339),
+		formatValue(exe),
+		os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write log file header: %v\n", err)
+	}
+}
+
+// WithFileHeader writes a comment line with the pid, start time, executable
+// and arguments of the process at the top of each log file the logger
+// creates with WithLogBasePath. Existing files are appended to unchanged.
+func WithFileHeader(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.fileHeader = enabled
+	})
+}
+
+func WithInfoLogFileFactory(open func() (io.Writer, error)) Option {
+	return OptionFunc(func(o *options) {
+		o.infoLogFile = &lazyWriter{open: open}
+	})
+}
+
+func WithErrorLogFileFactory(open func() (io.Writer, error)) Option {
+	return OptionFunc(func(o *options) {
+		o.errorLogFile = &lazyWriter{open: open}
+	})
+}
+
+func WithoutTimestamp() Option {
+	return OptionFunc(func(o *options) {
+		o.withoutTimestamp = true
+	})
+}
+
+func WithLogFlags(flags int) Option {
+	return OptionFunc(func(o *options) {
+		o.logFlags = flags
+	})
+}
+
+// WithAlignedLevels replaces the level tags of text output with the level
+// name padded to five characters and the tag separator, such as "INFO  " and
+// "ERROR ".
+func WithAlignedLevels(enabled bool) Option {
+	return OptionFunc(func(o *options) {
+		o.alignedLevels = enabled
+	})
+}
+
+// WithTagSeparator replaces the level tags of text output with the level
+// name followed by sep, such as "DEBUG | " for " | ". It defaults to a space
+// with WithAlignedLevels.
+func WithTagSeparator(sep string) Option {
+	return OptionFunc(func(o *options) {
+		o.tagSeparator = &sep
+	})
+}
+
+// WithLevelFlags sets the log flags of individual levels. Levels missing
+// from flags use the flags set by WithLogFlags.
+func WithLevelFlags(flags map[Level]int) Option {
+	return OptionFunc(func(o *options) {
+		o.levelFlags = make(map[Level]int, len(flags))
+		for level, f := range flags {
+			o.levelFlags[level] = f
+		}
 	})
 }
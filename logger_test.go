@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSilentSkipsTees(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithSilent(true), WithTee(&buf, JSONFormatter{}, Debug))
+	l.Info("hello")
+
+	if buf.Len() != 0 {
+		t.Errorf("tee written by a silent logger: %q", buf.String())
+	}
+}
+
+func TestTeeRejectsNilFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewWithError(WithTee(&buf, nil, Debug)); err == nil {
+		t.Fatal("NewWithError accepted a nil tee formatter")
+	}
+}
+
+type expensiveStringer struct {
+	calls *int
+}
+
+func (s expensiveStringer) String() string {
+	*s.calls++
+	return strings.Repeat("x", 1024)
+}
+
+func TestDisabledLevelSkipsStringer(t *testing.T) {
+	var calls int
+	s := expensiveStringer{calls: &calls}
+	l := New(WithLevel(Info), WithSilent(true))
+
+	l.Debug(s)
+	l.Debugf("%v", s)
+	l.Debugln(s)
+	l.DebugKV("msg", "value", s)
+
+	if calls != 0 {
+		t.Errorf("String called %d times at a disabled level", calls)
+	}
+}
+
+func BenchmarkDisabledLevelStringer(b *testing.B) {
+	var calls int
+	s := expensiveStringer{calls: &calls}
+	l := New(WithLevel(Info), WithSilent(true))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug(s)
+	}
+	if calls != 0 {
+		b.Fatalf("String called %d times at a disabled level", calls)
+	}
+}
+
+func BenchmarkContention(b *testing.B) {
+	discard := WithWriterMiddleware(func(io.Writer) io.Writer { return ioutil.Discard })
+	for _, lockFree := range []bool{false, true} {
+		b.Run(fmt.Sprintf("lockfree=%v", lockFree), func(b *testing.B) {
+			l := New(WithFormatter(JSONFormatter{}), discard, WithLockFreeWrites(lockFree))
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Info("contended")
+				}
+			})
+		})
+	}
+}
+
+func TestSequenceNumbersFollowWriteOrder(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(
+		WithLogFlags(0),
+		WithSequenceNumbers(true),
+		WithInfoLogFile(&buf),
+		WithWriterMiddleware(func(w io.Writer) io.Writer {
+			if w == os.Stdout {
+				return ioutil.Discard
+			}
+			return w
+		}),
+	)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				l.Info("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		if want := fmt.Sprintf("INFO : concurrent seq=%d", i+1); line != want {
+			t.Fatalf("line %d = %q, want %q", i, line, want)
+		}
+	}
+	if len(lines) != 8000 {
+		t.Errorf("got %d lines, want 8000", len(lines))
+	}
+}
+
+func TestSequenceNumbersGapForDroppedRecords(t *testing.T) {
+	ch := make(chan Record, 16)
+	l := New(WithSilent(true), WithChannel(ch), WithSequenceNumbers(true), WithRateLimit(Info, 1, 2))
+	for i := 0; i < 10; i++ {
+		l.Info("limited")
+	}
+	l.Warn("after")
+	close(ch)
+
+	var seqs []uint64
+	for r := range ch {
+		seqs = append(seqs, r.Fields[0].Value.(uint64))
+	}
+	if got, want := fmt.Sprint(seqs), "[1 2 11]"; got != want {
+		t.Errorf("seq = %s, want %s", got, want)
+	}
+	if got := l.DroppedCounts().RateLimited; got != 8 {
+		t.Errorf("RateLimited = %d, want 8", got)
+	}
+}
+
+func TestStartupBanner(t *testing.T) {
+	f := &memFile{}
+	New(WithStartupBanner(true), WithInfoLogFile(f), WithFormatter(LogfmtFormatter{}), discardStdout())
+
+	line := f.String()
+	if !strings.Contains(line, "format=logfmt") {
+		t.Errorf("banner %q does not name the format", line)
+	}
+	if strings.Contains(line, "file=") {
+		t.Errorf("banner %q has a caller", line)
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultOnceCapacity = 1024
+
+type onceFilter struct {
+	evictions uint64
+
+	keyFunc  func(Record) string
+	capacity int
+
+	mu    sync.Mutex
+	order *list.List
+	seen  map[string]*list.Element
+}
+
+func newOnceFilter(keyFunc func(Record) string, capacity int) *onceFilter {
+	if capacity <= 0 {
+		capacity = defaultOnceCapacity
+	}
+
+	return &onceFilter{
+		keyFunc:  keyFunc,
+		capacity: capacity,
+		order:    list.New(),
+		seen:     make(map[string]*list.Element),
+	}
+}
+
+// first reports whether r is the first record with its key. Records with an
+// empty key are always first.
+func (f *onceFilter) first(r Record) bool {
+	key := f.keyFunc(r)
+	if key == "" {
+		return true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if e, ok := f.seen[key]; ok {
+		f.order.MoveToFront(e)
+		return false
+	}
+
+	f.seen[key] = f.order.PushFront(key)
+	if f.order.Len() > f.capacity {
+		oldest := f.order.Back()
+		f.order.Remove(oldest)
+		delete(f.seen, oldest.Value.(string))
+		atomic.AddUint64(&f.evictions, 1)
+	}
+
+	return true
+}
+
+// LogOnceEvictions returns the number of keys forgotten by WithLogOncePerKey
+// to stay within its capacity. Records with a forgotten key are logged again.
+func (l *Logger) LogOnceEvictions() uint64 {
+	if l.once == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&l.once.evictions)
+}
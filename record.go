@@ -0,0 +1,19 @@
+package logger
+
+import "time"
+
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field
+	Caller  Caller
+}
+
+// Caller is the call site of a record. It is zero when the log flags do not
+// include Lshortfile or Llongfile.
+type Caller struct {
+	Function string
+	File     string
+	Line     int
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+type runtimeStats struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func (l *Logger) startRuntimeStats(interval time.Duration) {
+	s := &runtimeStats{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	l.runtimeStats = s
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var m runtime.MemStats
+		for {
+			select {
+			case <-ticker.C:
+				if !l.enabled(Info) {
+					continue
+				}
+				runtime.ReadMemStats(&m)
+				l.output(Info, "runtime stats", []Field{
+					{Key: "goroutines", Value: runtime.NumGoroutine()},
+					{Key: "heap_alloc", Value: m.HeapAlloc},
+					{Key: "num_gc", Value: m.NumGC},
+				}, false)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *runtimeStats) close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
@@ -0,0 +1,18 @@
+package logger
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStatsHaveNoCaller(t *testing.T) {
+	ch := make(chan Record, 1)
+	l := New(WithSilent(true), WithChannel(ch), WithLogFlags(log.Lshortfile), WithRuntimeStats(time.Millisecond))
+	defer l.Close()
+
+	r := <-ch
+	if r.Message != "runtime stats" || r.Caller != (Caller{}) {
+		t.Errorf("record = %q with caller %+v, want runtime stats without a caller", r.Message, r.Caller)
+	}
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const samplerTick = time.Second
+
+// maxSamplerKeys caps the number of messages the burst sampler tracks per
+// tick. Records with further messages are written unsampled: they are new,
+// so they would be within the first ones anyway.
+const maxSamplerKeys = 10000
+
+type samplerKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCount struct {
+	n       int
+	dropped uint64
+}
+
+type sampler struct {
+	first      int
+	thereafter int
+
+	mu     sync.Mutex
+	reset  time.Time
+	counts map[samplerKey]*sampleCount
+}
+
+func newSampler(first, thereafter int) *sampler {
+	return &sampler{
+		first:      first,
+		thereafter: thereafter,
+		counts:     make(map[samplerKey]*sampleCount),
+	}
+}
+
+func (s *sampler) sample(level Level, msg string, now time.Time) (ok bool, dropped uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.reset) >= samplerTick {
+		// Pending drop counts are carried over one tick only, so that keys
+		// which do not recur are forgotten. DroppedCounts still has them.
+		counts := make(map[samplerKey]*sampleCount)
+		for key, c := range s.counts {
+			if c.n > 0 && c.dropped > 0 {
+				counts[key] = &sampleCount{dropped: c.dropped}
+			}
+		}
+		s.counts = counts
+		s.reset = now
+	}
+
+	key := samplerKey{level: level, msg: msg}
+	c, found := s.counts[key]
+	if !found {
+		if len(s.counts) >= maxSamplerKeys {
+			return true, 0
+		}
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.n++
+
+	if c.n > s.first && (s.thereafter <= 0 || (c.n-s.first)%s.thereafter != 0) {
+		c.dropped++
+		return false, 0
+	}
+
+	dropped = c.dropped
+	c.dropped = 0
+
+	return true, dropped
+}
+
+type percentSampler struct {
+	state      uint64
+	kept       [Fatal + 1]uint64
+	thresholds [Fatal + 1]uint64
+	enabled    [Fatal + 1]bool
+}
+
+func newPercentSampler(percents map[Level]float64) *percentSampler {
+	s := &percentSampler{state: uint64(time.Now().UnixNano())}
+	for level, percent := range percents {
+		if level < Debug || level >= Fatal {
+			continue
+		}
+		s.enabled[level] = true
+		switch {
+		case percent <= 0:
+			s.thresholds[level] = 0
+		case percent >= 100:
+			s.enabled[level] = false
+		default:
+			s.thresholds[level] = uint64(percent / 100 * math.MaxUint64)
+		}
+	}
+	return s
+}
+
+// keep reports whether to write a record at level, using splitmix64 over an
+// atomic counter so concurrent callers never share a lock.
+func (s *percentSampler) keep(level Level) bool {
+	if !s.enabled[level] {
+		return true
+	}
+
+	z := atomic.AddUint64(&s.state, 0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	if z >= s.thresholds[level] {
+		return false
+	}
+	atomic.AddUint64(&s.kept[level], 1)
+	return true
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPercentSamplingCounts(t *testing.T) {
+	l := New(WithSilent(true), WithPercentSampling(Debug, 25))
+	const n = 10000
+	for i := 0; i < n; i++ {
+		l.Debug("sampled")
+		l.Info("not sampled")
+	}
+
+	stats := l.DroppedCounts()
+	if stats.Kept+stats.Sampled != n {
+		t.Fatalf("Kept+Sampled = %d+%d, want %d", stats.Kept, stats.Sampled, n)
+	}
+	if stats.Kept < n/5 || stats.Kept > n*3/10 {
+		t.Errorf("Kept = %d of %d, want about 25%%", stats.Kept, n)
+	}
+	if stats.ByLevel[Info] != 0 {
+		t.Errorf("ByLevel[Info] = %d, want 0", stats.ByLevel[Info])
+	}
+}
+
+func TestBurstSamplerForgetsKeys(t *testing.T) {
+	s := newSampler(1, 0)
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		msg := fmt.Sprintf("id %d", i)
+		s.sample(Info, msg, now)
+		s.sample(Info, msg, now)
+	}
+	for i := 1; i <= 3; i++ {
+		s.sample(Info, "other", now.Add(time.Duration(i)*samplerTick))
+	}
+
+	if n := len(s.counts); n != 1 {
+		t.Errorf("sampler tracks %d keys, want 1", n)
+	}
+}
+
+func TestBurstSamplerCapsKeys(t *testing.T) {
+	s := newSampler(1, 0)
+	now := time.Now()
+	for i := 0; i < maxSamplerKeys+10; i++ {
+		if ok, _ := s.sample(Info, fmt.Sprintf("id %d", i), now); !ok {
+			t.Fatalf("first record of key %d sampled out", i)
+		}
+	}
+
+	if n := len(s.counts); n != maxSamplerKeys {
+		t.Errorf("sampler tracks %d keys, want %d", n, maxSamplerKeys)
+	}
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSinkBatchSize = 100
+	defaultSinkInterval  = time.Second
+	defaultSinkRetries   = 3
+	defaultSinkBackoff   = 100 * time.Millisecond
+	defaultSinkQueueSize = 10000
+)
+
+type sinkWriter struct {
+	publish        func([]byte) error
+	batchSize      int
+	interval       time.Duration
+	retries        int
+	backoff        time.Duration
+	queueSize      int
+	flushOnNewline bool
+
+	mu      sync.Mutex
+	pending [][]byte
+	closed  bool
+
+	loop batchLoop
+}
+
+type SinkOption interface {
+	apply(w *sinkWriter)
+}
+
+type sinkOptionFunc func(w *sinkWriter)
+
+func (f sinkOptionFunc) apply(w *sinkWriter) {
+	f(w)
+}
+
+// WithSinkBatch sets the number of records per batch and how often queued
+// records are published. Non-positive values keep the defaults.
+func WithSinkBatch(size int, interval time.Duration) SinkOption {
+	return sinkOptionFunc(func(w *sinkWriter) {
+		w.batchSize = size
+		w.interval = interval
+	})
+}
+
+// WithSinkFlushOnNewline sends each write as soon as it arrives instead of
+// waiting for a full batch or the interval, trading throughput for latency.
+func WithSinkFlushOnNewline(enabled bool) SinkOption {
+	return sinkOptionFunc(func(w *sinkWriter) {
+		w.flushOnNewline = enabled
+	})
+}
+
+func WithSinkRetry(retries int, backoff time.Duration) SinkOption {
+	return sinkOptionFunc(func(w *sinkWriter) {
+		w.retries = retries
+		w.backoff = backoff
+	})
+}
+
+func WithSinkQueueSize(n int) SinkOption {
+	return sinkOptionFunc(func(w *sinkWriter) {
+		w.queueSize = n
+	})
+}
+
+// NewSinkWriter returns a writer that passes each record written to it to
+// publish, such as a function producing to a Kafka topic. Records are queued
+// and published in batches when a batch fills or on an interval. A failed
+// publish is retried with exponential backoff; records still failing stay
+// queued for the next batch, and the oldest are dropped once the queue is
+// full. Close publishes the queued records.
+func NewSinkWriter(publish func([]byte) error, opts ...SinkOption) io.WriteCloser {
+	w := &sinkWriter{
+		publish:   publish,
+		batchSize: defaultSinkBatchSize,
+		interval:  defaultSinkInterval,
+		retries:   defaultSinkRetries,
+		backoff:   defaultSinkBackoff,
+		queueSize: defaultSinkQueueSize,
+	}
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+
+	if w.batchSize <= 0 {
+		w.batchSize = defaultSinkBatchSize
+	}
+	if w.interval <= 0 {
+		w.interval = defaultSinkInterval
+	}
+
+	w.loop.start(w.interval, w.send)
+
+	return w
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("sink writer is closed")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	w.enqueue(append([]byte(nil), p...))
+
+	if w.flushOnNewline || len(w.pending) >= w.batchSize {
+		w.loop.notify()
+	}
+
+	return len(p), nil
+}
+
+// enqueue appends records to the queue, dropping the oldest ones beyond
+// its size. The caller must hold w.mu.
+func (w *sinkWriter) enqueue(records ...[]byte) {
+	w.pending = append(w.pending, records...)
+	if over := len(w.pending) - w.queueSize; over > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to queue %d log records: sink queue is full\n", over)
+		w.pending = w.pending[over:]
+	}
+}
+
+func (w *sinkWriter) send(final bool) {
+	w.mu.Lock()
+	records := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for i, record := range records {
+		err := retry(w.retries, w.backoff, func() error {
+			return w.publish(record)
+		})
+		if err == nil {
+			continue
+		}
+
+		if final {
+			fmt.Fprintf(os.Stderr, "Failed to publish %d log records: %v\n", len(records)-i, err)
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "Failed to publish log record: %v\n", err)
+		w.mu.Lock()
+		failed := append(records[i:len(records):len(records)], w.pending...)
+		w.pending = nil
+		w.enqueue(failed...)
+		w.mu.Unlock()
+		return
+	}
+}
+
+func (w *sinkWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.loop.stop()
+
+	return nil
+}
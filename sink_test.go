@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSinkIgnoresEmptyWrites(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		records []string
+	)
+	w := NewSinkWriter(func(p []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, string(p))
+		return nil
+	})
+	if _, err := w.Write(nil); err != nil {
+		t.Fatalf("Write(nil): %v", err)
+	}
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(records) != 1 || records[0] != "hello\n" {
+		t.Errorf("published %q, want [\"hello\\n\"]", records)
+	}
+}
+
+func TestSinkBatchDefaultsInterval(t *testing.T) {
+	w := NewSinkWriter(func([]byte) error { return nil }, WithSinkBatch(10, 0))
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestSinkFlushOnNewline(t *testing.T) {
+	published := make(chan string, 1)
+	w := NewSinkWriter(func(p []byte) error {
+		published <- string(p)
+		return nil
+	}, WithSinkBatch(100, time.Hour), WithSinkFlushOnNewline(true))
+	defer w.Close()
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	select {
+	case got := <-published:
+		if got != "hello\n" {
+			t.Errorf("published %q, want %q", got, "hello\n")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("record not published before the batch filled")
+	}
+}
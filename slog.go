@@ -0,0 +1,61 @@
+//go:build go1.21
+
+package logger
+
+import "log/slog"
+
+func attrFields(prefix string, attrs []slog.Attr, fields []Field) []Field {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if a.Key == "" && v.Kind() != slog.KindGroup {
+			continue
+		}
+
+		key := a.Key
+		if prefix != "" && key != "" {
+			key = prefix + "." + key
+		} else if key == "" {
+			key = prefix
+		}
+
+		if v.Kind() == slog.KindGroup {
+			fields = attrFields(key, v.Group(), fields)
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: v.Any()})
+	}
+	return fields
+}
+
+func (l *Logger) DebugAttrs(msg string, attrs ...slog.Attr) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, msg, attrFields("", attrs, nil))
+}
+
+func (l *Logger) InfoAttrs(msg string, attrs ...slog.Attr) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, msg, attrFields("", attrs, nil))
+}
+
+func (l *Logger) WarnAttrs(msg string, attrs ...slog.Attr) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, msg, attrFields("", attrs, nil))
+}
+
+func (l *Logger) ErrorAttrs(msg string, attrs ...slog.Attr) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, msg, attrFields("", attrs, nil))
+}
+
+func (l *Logger) FatalAttrs(msg string, attrs ...slog.Attr) {
+	l.log(Fatal, msg, attrFields("", attrs, nil))
+	l.exit(l.exitCode, msg)
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	maxStructDepth = 32
+	structCycle    = "!CYCLE"
+	structTooDeep  = "!MAXDEPTH"
+)
+
+func structFields(name string, v interface{}) []Field {
+	w := structWalker{visited: make(map[uintptr]bool)}
+	return w.appendFields(nil, name, reflect.ValueOf(v), 0)
+}
+
+// structWalker flattens a struct, replacing a pointer back to a struct being
+// flattened with structCycle and anything nested deeper than maxStructDepth
+// with structTooDeep.
+type structWalker struct {
+	visited map[uintptr]bool
+}
+
+func (w *structWalker) appendFields(fields []Field, prefix string, v reflect.Value, depth int) []Field {
+	if depth > maxStructDepth {
+		return append(fields, Field{Key: prefix, Value: structTooDeep})
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return append(fields, Field{Key: prefix, Value: nil})
+		}
+		if v.Kind() == reflect.Ptr {
+			p := v.Pointer()
+			if w.visited[p] {
+				return append(fields, Field{Key: prefix, Value: structCycle})
+			}
+			w.visited[p] = true
+			defer delete(w.visited, p)
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return append(fields, Field{Key: prefix, Value: nil})
+	}
+	if v.Kind() != reflect.Struct || isLeaf(v) {
+		return append(fields, Field{Key: prefix, Value: v.Interface()})
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fields = w.appendFields(fields, prefix, fv, depth+1)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		fields = w.appendFields(fields, key, fv, depth+1)
+	}
+
+	return fields
+}
+
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag != "" {
+		return tag, true
+	}
+	if sf.Anonymous {
+		return "", true
+	}
+	return sf.Name, true
+}
+
+func isLeaf(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+	switch v.Interface().(type) {
+	case fmt.Stringer, error:
+		return true
+	}
+	if v.CanAddr() {
+		switch v.Addr().Interface().(type) {
+		case fmt.Stringer, error:
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logger) DebugStruct(name string, v interface{}) {
+	if !l.enabled(Debug) {
+		return
+	}
+	l.log(Debug, "", structFields(name, v))
+}
+
+func (l *Logger) InfoStruct(name string, v interface{}) {
+	if !l.enabled(Info) {
+		return
+	}
+	l.log(Info, "", structFields(name, v))
+}
+
+func (l *Logger) WarnStruct(name string, v interface{}) {
+	if !l.enabled(Warn) {
+		return
+	}
+	l.log(Warn, "", structFields(name, v))
+}
+
+func (l *Logger) ErrorStruct(name string, v interface{}) {
+	if !l.enabled(Error) {
+		return
+	}
+	l.log(Error, "", structFields(name, v))
+}
+
+func (l *Logger) FatalStruct(name string, v interface{}) {
+	l.log(Fatal, "", structFields(name, v))
+	l.exit(l.exitCode, name)
+}
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// deriveOptions returns the options of l with opts applied on top, and
+// whether the levels of l should be kept because opts do not set any. The
+// caller must hold l.cfg.
+func (l *Logger) deriveOptions(opts []Option) (options, bool) {
+	o := l.opts
+	o.fields = append([]Field(nil), o.fields...)
+	o.onFatal = append([]func(){}, o.onFatal...)
+	o.tees = append([]tee(nil), o.tees...)
+	o.enabledLevels = nil
+	o.level = -1
+	o.startupBanner = false
+	o.runtimeStats = 0
+	o.suppress = nil
+	o.opened = nil
+	o.err = nil
+	if o.levelFlags != nil {
+		levelFlags := make(map[Level]int, len(o.levelFlags))
+		for level, flags := range o.levelFlags {
+			levelFlags[level] = flags
+		}
+		o.levelFlags = levelFlags
+	}
+	if o.rateLimits != nil {
+		rateLimits := make(map[Level]rateLimit, len(o.rateLimits))
+		for level, limit := range o.rateLimits {
+			rateLimits[level] = limit
+		}
+		o.rateLimits = rateLimits
+	}
+	if o.samplePercents != nil {
+		samplePercents := make(map[Level]float64, len(o.samplePercents))
+		for level, percent := range o.samplePercents {
+			samplePercents[level] = percent
+		}
+		o.samplePercents = samplePercents
+	}
+
+	o.applyAll(opts)
+
+	inherit := o.level == -1 && o.enabledLevels == nil
+	if inherit {
+		o.level = l.opts.level
+	}
+
+	return o, inherit
+}
+
+// Sub returns a logger configured like l with opts applied on top. It
+// shares the writers of l: destination options are ignored, and Close on
+// the returned logger leaves the writers open for l to close. Tees added
+// through opts belong to the returned logger and are closed by its Close. Fields given
+// with WithFields are added to those of l. Unless opts include
+// WithSuppressUntilReady, the returned logger holds records back together
+// with l until Ready is called on either.
+func (l *Logger) Sub(opts ...Option) *Logger {
+	l.cfg.RLock()
+	o, inherit := l.deriveOptions(opts)
+	o.writerSet = l.ws
+	inherited := len(l.opts.tees)
+	atomic.StoreUint32(&l.shared, 1)
+	l.cfg.RUnlock()
+
+	if o.err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logger: %v\n", o.err)
+	}
+	for _, c := range o.opened {
+		c.Close()
+	}
+	o.opened = nil
+
+	sub := newLogger(o)
+	sub.closers = nil
+	for _, t := range o.tees[inherited:] {
+		if c, ok := t.w.(io.Closer); ok {
+			sub.closers = append(sub.closers, c)
+		}
+	}
+	if sub.suppress == nil {
+		sub.suppress = l.suppress
+	}
+	if inherit {
+		atomic.StoreUint32(&sub.levels, atomic.LoadUint32(&l.levels))
+	}
+
+	return sub
+}
+
+// Reconfigure applies opts on top of the current configuration and swaps
+// in the resulting destinations, format, log flags, tees and levels at
+// once: each record is written entirely with the old or the new ones.
+// Writers the logger opened that the new configuration no longer uses are
+// closed, unless loggers created with Sub still write to them: those stay
+// open until Close. Other options are ignored.
+func (l *Logger) Reconfigure(opts ...Option) error {
+	l.cfg.Lock()
+
+	o, inherit := l.deriveOptions(opts)
+	if o.err != nil {
+		l.cfg.Unlock()
+		for _, c := range o.opened {
+			c.Close()
+		}
+		return o.err
+	}
+	o.opened = nil
+
+	old := l.closers
+	setOutputOptions(&l.opts, &o)
+	l.setOutput(&o)
+	if !inherit {
+		atomic.StoreUint32(&l.levels, levelsOf(&o))
+	}
+
+	var stale []io.Closer
+	for _, c := range old {
+		used := false
+		for _, n := range l.closers {
+			if c == n {
+				used = true
+				break
+			}
+		}
+		if !used {
+			stale = append(stale, c)
+		}
+	}
+
+	if atomic.SwapUint32(&l.shared, 0) != 0 {
+		l.retired = append(l.retired, stale...)
+		stale = nil
+	}
+
+	l.cfg.Unlock()
+
+	return closeAll(stale, l.quietClose)
+}
+
+// setOutputOptions copies the options that Reconfigure applies from src to
+// dst, so that loggers created with Sub later do not pick up the others.
+func setOutputOptions(dst, src *options) {
+	dst.level = src.level
+	dst.enabledLevels = src.enabledLevels
+	dst.formatter = src.formatter
+	dst.accessFormat = src.accessFormat
+	dst.withoutTimestamp = src.withoutTimestamp
+	dst.logFlags = src.logFlags
+	dst.levelFlags = src.levelFlags
+	dst.alignedLevels = src.alignedLevels
+	dst.tagSeparator = src.tagSeparator
+	dst.tees = src.tees
+	dst.writerSet = src.writerSet
+	dst.infoLogFile = src.infoLogFile
+	dst.errorLogFile = src.errorLogFile
+	dst.thresholdWriters = src.thresholdWriters
+	dst.middlewares = src.middlewares
+	dst.writerTimeout = src.writerTimeout
+	dst.writeLatency = src.writeLatency
+	dst.quietClose = src.quietClose
+}
+
+// WithNewCorrelationID returns a sub-logger of l that adds a correlation_id
+// field holding 8 random bytes in hex.
+func (l *Logger) WithNewCorrelationID() *Logger {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate correlation ID: %v\n", err)
+		return l.Sub()
+	}
+
+	return l.Sub(WithFields("correlation_id", hex.EncodeToString(id[:])))
+}
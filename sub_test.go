@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func discardStdout() Option {
+	return WithWriterMiddleware(func(w io.Writer) io.Writer {
+		if w == os.Stdout {
+			return ioutil.Discard
+		}
+		return w
+	})
+}
+
+func TestReconfigureKeepsWritersUsedBySub(t *testing.T) {
+	f1, f2 := &memFile{}, &memFile{}
+	l := New(WithLogFlags(0), WithInfoLogFile(f1), discardStdout())
+	s := l.Sub()
+
+	if err := l.Reconfigure(WithInfoLogFile(f2)); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if f1.closed {
+		t.Fatal("Reconfigure closed a writer still used by a sub-logger")
+	}
+	s.Info("from sub")
+	l.Info("from parent")
+
+	if got, want := f1.String(), "INFO : from sub\n"; got != want {
+		t.Errorf("old file = %q, want %q", got, want)
+	}
+	if got, want := f2.String(), "INFO : from parent\n"; got != want {
+		t.Errorf("new file = %q, want %q", got, want)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !f1.closed || !f2.closed {
+		t.Errorf("Close left files open: old %v, new %v", !f1.closed, !f2.closed)
+	}
+}
+
+func TestReconfigureClosesUnusedWriters(t *testing.T) {
+	f1 := &memFile{}
+	l := New(WithInfoLogFile(f1), discardStdout())
+
+	if err := l.Reconfigure(WithInfoLogFile(&memFile{})); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if !f1.closed {
+		t.Error("Reconfigure left the replaced writer open")
+	}
+}
+
+func TestReconfigureIgnoresOtherOptions(t *testing.T) {
+	f := &memFile{}
+	l := New(WithLogFlags(0), WithInfoLogFile(f), discardStdout())
+
+	if err := l.Reconfigure(WithFields("a", 1), WithPrefix("p: ")); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	l.Sub().Info("hello")
+
+	if got, want := f.String(), "INFO : hello\n"; got != want {
+		t.Errorf("sub output = %q, want %q", got, want)
+	}
+}
+
+func TestSubClosesOwnTees(t *testing.T) {
+	parentTee, subTee := &memFile{}, &memFile{}
+	l := New(WithTee(parentTee, JSONFormatter{}, Debug), discardStdout())
+	s := l.Sub(WithTee(subTee, JSONFormatter{}, Debug))
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !subTee.closed {
+		t.Error("Close left the sub-logger's own tee open")
+	}
+	if parentTee.closed {
+		t.Error("Close on the sub-logger closed the parent's tee")
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultSuppressCapacity = 1000
+
+type heldRecord struct {
+	l     *Logger
+	level Level
+	text  string
+	r     Record
+}
+
+type suppressor struct {
+	level    Level
+	capacity int
+
+	mu     sync.Mutex
+	ready  bool
+	failed bool
+	held   []heldRecord
+}
+
+// holdUntilReady reports whether the record is held back until Ready, and
+// returns the held records to write before it. The caller must hold l.cfg.
+func (l *Logger) holdUntilReady(level Level, text string, r Record) (bool, []heldRecord) {
+	s := l.suppress
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return false, nil
+	}
+
+	if level >= Error {
+		s.failed = true
+	}
+	if level == Fatal {
+		s.ready = true
+		held := s.held
+		s.held = nil
+		return false, held
+	}
+	if level >= s.level {
+		return false, nil
+	}
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	s.held = append(s.held, heldRecord{l: l, level: level, text: text, r: r})
+	if over := len(s.held) - s.capacity; over > 0 {
+		for _, h := range s.held[:over] {
+			h.l.drop(h.level, dropSuppressed)
+		}
+		s.held = s.held[over:]
+	}
+
+	return true, nil
+}
+
+// writeHeld writes held records through the loggers that logged them. The
+// caller must hold l.cfg.
+func (l *Logger) writeHeld(held []heldRecord) {
+	for _, h := range held {
+		if h.l == l {
+			l.write(h.level, h.text, h.r)
+			continue
+		}
+		h.l.cfg.RLock()
+		h.l.write(h.level, h.text, h.r)
+		h.l.cfg.RUnlock()
+	}
+}
+
+// Ready ends the holding started by WithSuppressUntilReady, for l and the
+// loggers created from it with Sub. The held records are written if an
+// Error or Fatal record was logged since the logger was created, and
+// discarded otherwise; DroppedCounts counts the discarded ones as
+// suppressed.
+func (l *Logger) Ready() {
+	if l.suppress == nil {
+		return
+	}
+
+	s := l.suppress
+	s.mu.Lock()
+	if s.ready {
+		s.mu.Unlock()
+		return
+	}
+	s.ready = true
+	held := s.held
+	s.held = nil
+	failed := s.failed
+	s.mu.Unlock()
+
+	if !failed {
+		for _, h := range held {
+			h.l.drop(h.level, dropSuppressed)
+		}
+		return
+	}
+
+	l.cfg.RLock()
+	defer l.cfg.RUnlock()
+
+	l.writeHeld(held)
+}
@@ -0,0 +1,18 @@
+package logger
+
+import "testing"
+
+func TestSuppressOverflowCountsAsSuppressed(t *testing.T) {
+	l := New(WithSilent(true), WithSuppressUntilReady(Warn, 2))
+	for i := 0; i < 5; i++ {
+		l.Info("held")
+	}
+
+	stats := l.DroppedCounts()
+	if stats.Suppressed != 3 {
+		t.Errorf("Suppressed = %d, want 3", stats.Suppressed)
+	}
+	if got := l.ChannelDropped(); got != 0 {
+		t.Errorf("ChannelDropped = %d, want 0", got)
+	}
+}
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookBatchSize  = 20
+	defaultWebhookInterval   = time.Second
+	defaultWebhookRetries    = 3
+	defaultWebhookBackoff    = 500 * time.Millisecond
+	defaultWebhookMaxPending = 1000
+)
+
+type webhookWriter struct {
+	url            string
+	client         *http.Client
+	batchSize      int
+	interval       time.Duration
+	retries        int
+	backoff        time.Duration
+	maxPending     int
+	limiter        *rateLimiter
+	flushOnNewline bool
+
+	mu      sync.Mutex
+	pending []string
+	closed  bool
+
+	loop batchLoop
+}
+
+type WebhookOption interface {
+	apply(w *webhookWriter)
+}
+
+type webhookOptionFunc func(w *webhookWriter)
+
+func (f webhookOptionFunc) apply(w *webhookWriter) {
+	f(w)
+}
+
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return webhookOptionFunc(func(w *webhookWriter) {
+		w.client = client
+	})
+}
+
+// WithWebhookBatch sets the number of lines per post and how often pending
+// lines are sent. Non-positive values keep the defaults.
+func WithWebhookBatch(size int, interval time.Duration) WebhookOption {
+	return webhookOptionFunc(func(w *webhookWriter) {
+		w.batchSize = size
+		w.interval = interval
+	})
+}
+
+// WithWebhookFlushOnNewline sends each write as soon as it arrives instead of
+// waiting for a full batch or the interval, trading throughput for latency.
+func WithWebhookFlushOnNewline(enabled bool) WebhookOption {
+	return webhookOptionFunc(func(w *webhookWriter) {
+		w.flushOnNewline = enabled
+	})
+}
+
+func WithWebhookRetry(retries int, backoff time.Duration) WebhookOption {
+	return webhookOptionFunc(func(w *webhookWriter) {
+		w.retries = retries
+		w.backoff = backoff
+	})
+}
+
+func WithWebhookRateLimit(perSecond, burst int) WebhookOption {
+	return webhookOptionFunc(func(w *webhookWriter) {
+		w.limiter = newRateLimiter(perSecond, burst)
+	})
+}
+
+// NewWebhookWriter returns a writer that POSTs the lines written to it to url
+// as a JSON object {"text": "..."}, which Slack and Teams incoming webhooks
+// accept. Lines are batched and sent when a batch fills or on an interval;
+// failed posts are retried with exponential backoff. Close sends any pending
+// lines. Attach it with WithThresholdWriter to alert on errors only.
+func NewWebhookWriter(url string, opts ...WebhookOption) io.WriteCloser {
+	w := &webhookWriter{
+		url:        url,
+		client:     http.DefaultClient,
+		batchSize:  defaultWebhookBatchSize,
+		interval:   defaultWebhookInterval,
+		retries:    defaultWebhookRetries,
+		backoff:    defaultWebhookBackoff,
+		maxPending: defaultWebhookMaxPending,
+	}
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = defaultWebhookBatchSize
+	}
+	if w.interval <= 0 {
+		w.interval = defaultWebhookInterval
+	}
+
+	w.loop.start(w.interval, w.send)
+
+	return w
+}
+
+func (w *webhookWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("webhook writer is closed")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	dropped := 0
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if len(w.pending) >= w.maxPending {
+			dropped++
+			continue
+		}
+		w.pending = append(w.pending, line)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to queue %d log lines: webhook queue is full\n", dropped)
+	}
+
+	if w.flushOnNewline || len(w.pending) >= w.batchSize {
+		w.loop.notify()
+	}
+
+	return len(p), nil
+}
+
+func (w *webhookWriter) send(final bool) {
+	w.mu.Lock()
+	if len(w.pending) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	if !final && w.limiter != nil {
+		if ok, _ := w.limiter.allow(time.Now()); !ok {
+			w.mu.Unlock()
+			return
+		}
+	}
+	lines := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{
+		Text: strings.Join(lines, "\n"),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode webhook payload: %v\n", err)
+		return
+	}
+
+	err = retry(w.retries, w.backoff, func() error {
+		return w.post(body)
+	})
+	if err == nil {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Failed to post %d log lines to webhook: %v\n", len(lines), err)
+}
+
+func (w *webhookWriter) post(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (w *webhookWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.loop.stop()
+
+	return nil
+}
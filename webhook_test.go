@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookIgnoresEmptyWrites(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	w := NewWebhookWriter(srv.URL)
+	if _, err := w.Write(nil); err != nil {
+		t.Fatalf("Write(nil): %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(bodies) != 0 {
+		t.Errorf("posted %q for an empty write", bodies)
+	}
+}
+
+func TestWebhookBatchDefaultsInterval(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	w := NewWebhookWriter(srv.URL, WithWebhookBatch(10, 0))
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestWebhookFlushOnNewline(t *testing.T) {
+	posted := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		posted <- string(b)
+	}))
+	defer srv.Close()
+
+	w := NewWebhookWriter(srv.URL, WithWebhookBatch(100, time.Hour), WithWebhookFlushOnNewline(true))
+	defer w.Close()
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	select {
+	case got := <-posted:
+		if got != `{"text":"hello"}` {
+			t.Errorf("posted %s, want {\"text\":\"hello\"}", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("line not posted before the batch filled")
+	}
+}
@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type lazyWriter struct {
+	open func() (io.Writer, error)
+
+	mu     sync.Mutex
+	opened bool
+	w      io.Writer
+	err    error
+}
+
+func (w *lazyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.opened {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		w.opened = true
+		w.w, w.err = w.open()
+		if w.err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open log: %v\n", w.err)
+		}
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	return w.w.Write(p)
+}
+
+func (w *lazyWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// lineLevel parses the level tag at the start of p. Besides the default tags
+// it accepts a level name followed by spaces and an optional ':' or '|'
+// separator, as written with WithAlignedLevels or WithTagSeparator. Lines
+// written by JSONFormatter or LogfmtFormatter are recognised by their level
+// field and returned whole.
+func lineLevel(p []byte) (Level, []byte, bool) {
+	for level, tag := range levelTags {
+		if len(p) >= len(tag) && string(p[:len(tag)]) == tag {
+			return Level(level), p[len(tag):], true
+		}
+	}
+
+	for level := Debug; level <= Fatal; level++ {
+		name := level.String()
+		if len(p) <= len(name) || string(p[:len(name)]) != name {
+			continue
+		}
+		rest := p[len(name):]
+		if rest[0] != ' ' && rest[0] != ':' && rest[0] != '|' {
+			continue
+		}
+		rest = bytes.TrimLeft(rest, " ")
+		rest = bytes.TrimLeft(rest, ":|")
+		return level, bytes.TrimLeft(rest, " "), true
+	}
+
+	if level, ok := fieldLevel(p); ok {
+		return level, p, true
+	}
+
+	return Info, p, false
+}
+
+var (
+	jsonLevelKey   = []byte(`"level":`)
+	logfmtLevelKey = []byte("level=")
+)
+
+// fieldLevel finds the level field of a JSON or logfmt record.
+func fieldLevel(p []byte) (Level, bool) {
+	var value []byte
+	if bytes.HasPrefix(bytes.TrimLeft(p, " \t"), []byte("{")) {
+		i := bytes.Index(p, jsonLevelKey)
+		if i < 0 {
+			return 0, false
+		}
+		value = bytes.TrimLeft(p[i+len(jsonLevelKey):], " ")
+		if len(value) == 0 || value[0] != '"' {
+			return 0, false
+		}
+		value = value[1:]
+		if j := bytes.IndexByte(value, '"'); j >= 0 {
+			value = value[:j]
+		}
+	} else {
+		i := bytes.Index(p, logfmtLevelKey)
+		if i < 0 || i > 0 && p[i-1] != ' ' {
+			return 0, false
+		}
+		value = p[i+len(logfmtLevelKey):]
+		if j := bytes.IndexAny(value, " \n"); j >= 0 {
+			value = value[:j]
+		}
+	}
+
+	level, err := ParseLevel(string(value))
+	return level, err == nil
+}
+
+type levelPattern struct {
+	level Level
+	re    *regexp.Regexp
+}
+
+type levelWriter struct {
+	l        *Logger
+	level    Level
+	patterns []levelPattern
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *levelWriter) parse(line string) (Level, string) {
+	for _, p := range w.patterns {
+		loc := p.re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		if loc[0] == 0 {
+			line = strings.TrimLeft(line[loc[1]:], " \t")
+		}
+		return p.level, line
+	}
+	return w.level, line
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		level, line := w.parse(string(w.buf[:i]))
+		w.l.output(level, line, nil, false)
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+
+	return len(p), nil
+}
+
+func (l *Logger) Writer(level Level) io.Writer {
+	return &levelWriter{l: l, level: level}
+}
+
+// NewLevelParsingWriter returns a writer that logs each line to l at the
+// level whose pattern matches it, trying the most severe level first, or at
+// def when none does. A match at the start of the line is stripped.
+func NewLevelParsingWriter(l *Logger, def Level, patterns map[Level]*regexp.Regexp) io.Writer {
+	w := &levelWriter{l: l, level: def}
+	for level := Fatal; level >= Debug; level-- {
+		if re, ok := patterns[level]; ok && re != nil {
+			w.patterns = append(w.patterns, levelPattern{level: level, re: re})
+		}
+	}
+	return w
+}
+
+func (l *Logger) Writers() map[Level]io.Writer {
+	writers := make(map[Level]io.Writer)
+	for _, level := range Levels() {
+		writers[level] = l.Writer(level)
+	}
+	return writers
+}
+
+type timeoutWriter struct {
+	busy uint32
+
+	w        io.Writer
+	timeout  time.Duration
+	timeouts *uint64
+}
+
+func newTimeoutWriter(w io.Writer, timeout time.Duration, timeouts *uint64) *timeoutWriter {
+	return &timeoutWriter{
+		w:        w,
+		timeout:  timeout,
+		timeouts: timeouts,
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	if conn, ok := w.w.(net.Conn); ok {
+		conn.SetWriteDeadline(time.Now().Add(w.timeout))
+		n, err := conn.Write(p)
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			atomic.AddUint64(w.timeouts, 1)
+			return len(p), nil
+		}
+		return n, err
+	}
+
+	if !atomic.CompareAndSwapUint32(&w.busy, 0, 1) {
+		atomic.AddUint64(w.timeouts, 1)
+		return len(p), nil
+	}
+
+	buf := append([]byte(nil), p...)
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.w.Write(buf)
+		atomic.StoreUint32(&w.busy, 0)
+		done <- err
+	}()
+
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	case <-timer.C:
+		atomic.AddUint64(w.timeouts, 1)
+		return len(p), nil
+	}
+}
@@ -0,0 +1,25 @@
+package logger
+
+import "testing"
+
+func TestLineLevel(t *testing.T) {
+	tests := []struct {
+		line  string
+		level Level
+		msg   string
+	}{
+		{"WARN : disk low\n", Warn, "disk low\n"},
+		{"ERROR | boom", Error, "boom"},
+		{`{"time":"t","level":"ERROR","msg":"boom"}` + "\n", Error, `{"time":"t","level":"ERROR","msg":"boom"}` + "\n"},
+		{"{\n  \"level\": \"WARN\",\n  \"msg\": \"x\"\n}\n", Warn, "{\n  \"level\": \"WARN\",\n  \"msg\": \"x\"\n}\n"},
+		{"time=t level=DEBUG msg=x\n", Debug, "time=t level=DEBUG msg=x\n"},
+		{"no level here", Info, "no level here"},
+		{"xlevel=ERROR", Info, "xlevel=ERROR"},
+	}
+	for _, tt := range tests {
+		level, msg, _ := lineLevel([]byte(tt.line))
+		if level != tt.level || string(msg) != tt.msg {
+			t.Errorf("lineLevel(%q) = %v, %q; want %v, %q", tt.line, level, msg, tt.level, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type WriterSet struct {
+	writeTimeouts uint64
+
+	debugLog *log.Logger
+	infoLog  *log.Logger
+	warnLog  *log.Logger
+	errorLog *log.Logger
+	fatalLog *log.Logger
+
+	infoOutputs  []string
+	errorOutputs []string
+
+	writers   []io.Writer
+	latencies *latencies
+
+	mu         sync.Mutex
+	closers    []io.Closer
+	quietClose bool
+}
+
+func NewWriterSet(opts ...Option) *WriterSet {
+	o := newOptions(opts)
+	return newWriterSet(&o)
+}
+
+type destination struct {
+	name     string
+	w        io.Writer
+	minLevel Level
+	maxLevel Level
+	owned    bool
+}
+
+func newWriterSet(o *options) *WriterSet {
+	ws := &WriterSet{quietClose: o.quietClose}
+
+	dests := []destination{
+		{name: "stdout", w: os.Stdout, minLevel: Debug, maxLevel: Info},
+		{name: "stderr", w: os.Stderr, minLevel: Warn, maxLevel: Fatal},
+	}
+	if o.infoLogFile != nil {
+		dests = append(dests, destination{name: writerName(o.infoLogFile), w: o.infoLogFile, minLevel: Debug, maxLevel: Info, owned: true})
+	}
+	if o.errorLogFile != nil {
+		dests = append(dests, destination{name: writerName(o.errorLogFile), w: o.errorLogFile, minLevel: Warn, maxLevel: Fatal, owned: true})
+	}
+	for _, t := range o.thresholdWriters {
+		dests = append(dests, destination{name: writerName(t.w), w: t.w, minLevel: t.minLevel, maxLevel: Fatal, owned: true})
+	}
+	names := make(map[string]int)
+	for i := range dests {
+		dests[i].name = uniqueName(names, dests[i].name)
+	}
+
+	for _, d := range dests {
+		ws.writers = append(ws.writers, d.w)
+		if c, ok := d.w.(io.Closer); ok && d.owned {
+			ws.closers = append(ws.closers, c)
+		}
+		if d.minLevel <= Info && Info <= d.maxLevel {
+			ws.infoOutputs = append(ws.infoOutputs, d.name)
+		}
+		if d.minLevel <= Error && Error <= d.maxLevel {
+			ws.errorOutputs = append(ws.errorOutputs, d.name)
+		}
+	}
+
+	if o.writeLatency {
+		ws.latencies = &latencies{}
+	}
+	for i := range dests {
+		w := dests[i].w
+		if ws.latencies != nil {
+			w = ws.latencies.wrap(dests[i].name, w)
+		}
+		if o.writerTimeout > 0 {
+			w = newTimeoutWriter(w, o.writerTimeout, &ws.writeTimeouts)
+		}
+		for j := len(o.middlewares) - 1; j >= 0; j-- {
+			inner := w
+			w = o.middlewares[j](inner)
+			if c, ok := w.(io.Closer); ok && w != inner {
+				ws.closers = append(ws.closers, c)
+			}
+		}
+		dests[i].w = w
+	}
+
+	newLog := func(level Level, tag string) *log.Logger {
+		var writers []io.Writer
+		for _, d := range dests {
+			if d.minLevel <= level && level <= d.maxLevel {
+				writers = append(writers, d.w)
+			}
+		}
+		return log.New(fanoutWriter(writers), tag, o.flags(level)&^callerFlags)
+	}
+
+	tags := o.levelTags()
+
+	ws.debugLog = newLog(Debug, tags[Debug])
+	ws.infoLog = newLog(Info, tags[Info])
+	ws.warnLog = newLog(Warn, tags[Warn])
+	ws.errorLog = newLog(Error, tags[Error])
+	ws.fatalLog = newLog(Fatal, tags[Fatal])
+
+	return ws
+}
+
+// fanoutWriter writes to every writer even when some fail, unlike
+// io.MultiWriter, so a failing log file cannot block an alert sink. It
+// returns the first error.
+type fanoutWriter []io.Writer
+
+func (ws fanoutWriter) Write(p []byte) (int, error) {
+	var first error
+	for _, w := range ws {
+		if _, err := w.Write(p); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return 0, first
+	}
+	return len(p), nil
+}
+
+// uniqueName returns name, suffixed with its count when it has been seen
+// before, so that writers of the same type keep apart.
+func uniqueName(seen map[string]int, name string) string {
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		return name + "#" + strconv.Itoa(n)
+	}
+	return name
+}
+
+func (ws *WriterSet) Close() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return closeAll(ws.closers, ws.quietClose)
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type memFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, errors.New("write to closed file")
+	}
+	return f.Buffer.Write(p)
+}
+
+func (f *memFile) Close() error {
+	f.closed = true
+	return nil
+}
+
+type bufferedCloser struct {
+	*bufio.Writer
+}
+
+func (b bufferedCloser) Close() error {
+	return b.Flush()
+}
+
+func TestCloseFlushesBufferedOverFile(t *testing.T) {
+	f := &memFile{}
+	l := New(
+		WithLogFlags(0),
+		WithInfoLogFile(f),
+		WithWriterMiddleware(func(w io.Writer) io.Writer {
+			if w != f {
+				return ioutil.Discard
+			}
+			return bufferedCloser{bufio.NewWriterSize(w, 4096)}
+		}),
+	)
+	l.Info("hello")
+	l.Info("world")
+
+	if f.Len() != 0 {
+		t.Fatalf("file written before Close: %q", f.String())
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !f.closed {
+		t.Fatal("file not closed")
+	}
+	if got, want := f.String(), "INFO : hello\nINFO : world\n"; got != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+}
+
+func TestCloseFlushesBufferedCompressedFile(t *testing.T) {
+	f := &memFile{}
+	onFile := func(wrap func(io.Writer) io.Writer) Option {
+		return WithWriterMiddleware(func(w io.Writer) io.Writer {
+			if _, ok := w.(*gzip.Writer); ok || w == f {
+				return wrap(w)
+			}
+			return ioutil.Discard
+		})
+	}
+	l := New(
+		WithLogFlags(0),
+		WithInfoLogFile(f),
+		onFile(func(w io.Writer) io.Writer { return bufferedCloser{bufio.NewWriter(w)} }),
+		onFile(func(w io.Writer) io.Writer { return gzip.NewWriter(w) }),
+	)
+	l.Info("hello")
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	zr, err := gzip.NewReader(&f.Buffer)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got, want := string(b), "INFO : hello\n"; got != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+}
+
+func TestWriteLatencyKeepsSameTypeWritersApart(t *testing.T) {
+	l := New(
+		WithLogFlags(0),
+		WithWriteLatency(true),
+		WithThresholdWriter(&memFile{}, Info),
+		WithThresholdWriter(&memFile{}, Warn),
+		WithWriterMiddleware(func(w io.Writer) io.Writer {
+			if lw, ok := w.(*latencyWriter); ok && lw.w == os.Stdout {
+				return ioutil.Discard
+			}
+			return w
+		}),
+	)
+	l.Info("hello")
+
+	stats := l.WriteLatency()
+	if got := stats["*logger.memFile"].Count; got != 1 {
+		t.Errorf("first writer count = %d, want 1 (stats %v)", got, stats)
+	}
+	if got := stats["*logger.memFile#2"].Count; got != 0 {
+		t.Errorf("second writer count = %d, want 0 (stats %v)", got, stats)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestFailingDestinationDoesNotBlockOthers(t *testing.T) {
+	var alerts bytes.Buffer
+	l := New(
+		WithLogFlags(0),
+		WithErrorLogFile(failingWriter{}),
+		WithThresholdWriter(&alerts, Error),
+		WithWriterMiddleware(func(w io.Writer) io.Writer {
+			if w == os.Stderr {
+				return ioutil.Discard
+			}
+			return w
+		}),
+	)
+	l.Error("boom")
+
+	if got, want := alerts.String(), "ERROR: boom\n"; got != want {
+		t.Errorf("alert sink = %q, want %q", got, want)
+	}
+}